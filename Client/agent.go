@@ -0,0 +1,165 @@
+package Client
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"github.com/jffp113/Thesis_Client/conf"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+//bearerPrefix starts the Authorization header that holds the agent token
+const bearerPrefix = "Bearer "
+
+//AgentJob is a run the coordinator asks an agent to execute
+type AgentJob struct {
+	Handler     string        `json:"handler"`
+	Concurrency int           `json:"concurrency"`
+	Duration    time.Duration `json:"duration"`
+	//Config is the config file of the coordinator, paths in it
+	//such as sawtoothX.keyPath must exist on the agent machine.
+	//Secret references of the keys the handler reads are rejected by
+	//the agent, so that whoever reaches it cannot read its files and
+	//environment, the references of other keys are ignored
+	Config []byte `json:"config"`
+	//Profile of the config file the agent runs with
+	Profile string `json:"profile,omitempty"`
+	//Overrides of config keys applied over the profile
+	Overrides []conf.Override `json:"overrides,omitempty"`
+	//StartAt is when the workers start, in the agent clock
+	StartAt time.Time `json:"startAt"`
+	//Seed of the agent, the coordinator gives each agent its own
+	Seed int64 `json:"seed,omitempty"`
+	//KeepSamples is the number of requests the agent keeps as samples
+	KeepSamples int         `json:"keepSamples"`
+	Options     interface{} `json:"options,omitempty"`
+}
+
+//clockResponse is the answer of an agent to a clock request
+type clockResponse struct {
+	Time time.Time `json:"time"`
+}
+
+//Agent executes the jobs of a coordinator, one at a time,
+//and answers with their results including every sample
+type Agent struct {
+	mu       sync.Mutex
+	busy     bool
+	handlers map[string]Handler
+	used     map[string]bool
+	mux      *http.ServeMux
+	//token the coordinator must send, empty accepts any request
+	token string
+}
+
+func NewAgent() *Agent {
+	a := &Agent{handlers: make(map[string]Handler), used: make(map[string]bool)}
+	a.mux = http.NewServeMux()
+	a.mux.HandleFunc("/clock", a.clock)
+	a.mux.HandleFunc("/run", a.run)
+	return a
+}
+
+//AddHandler adds a handler that is not registered or replaces
+//a registered one, other handlers are created from the registry
+func (a *Agent) AddHandler(key string, handler Handler) {
+	a.handlers[key] = handler
+}
+
+//SetToken sets the token the coordinator must send as a bearer token
+func (a *Agent) SetToken(token string) {
+	a.token = token
+}
+
+func (a *Agent) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if a.token != "" {
+		given := strings.TrimPrefix(req.Header.Get("Authorization"), bearerPrefix)
+		if subtle.ConstantTimeCompare([]byte(given), []byte(a.token)) != 1 {
+			http.Error(w, "missing or wrong agent token", http.StatusUnauthorized)
+			return
+		}
+	}
+	a.mux.ServeHTTP(w, req)
+}
+
+func (a *Agent) clock(w http.ResponseWriter, req *http.Request) {
+	writeJSON(w, clockResponse{Time: time.Now()})
+}
+
+func (a *Agent) run(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var job AgentJob
+	if err := json.NewDecoder(req.Body).Decode(&job); err != nil {
+		http.Error(w, fmt.Sprintf("invalid job: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if !a.acquire() {
+		http.Error(w, "agent is busy with another job", http.StatusConflict)
+		return
+	}
+	defer a.release()
+
+	handler, err := resolveHandler(a.handlers, job.Handler)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	//handlers keep state such as pending transactions between runs
+	if a.used[job.Handler] {
+		if resetter, ok := handler.(Resetter); ok {
+			resetter.Reset()
+		}
+	}
+	a.used[job.Handler] = true
+
+	r := NewRequester()
+	r.SetContext(req.Context())
+	r.SetConfigData(job.Config)
+	r.SetProfile(job.Profile)
+	r.SetOverrides(job.Overrides)
+	r.SetConcurrentClients(job.Concurrency)
+	r.SetDuration(job.Duration)
+	r.SetStartAt(job.StartAt)
+	r.SetSeed(job.Seed)
+	r.SetKeepSamples(job.KeepSamples)
+	r.SetRunOptions(job.Options)
+	r.AddHandler(job.Handler, handler)
+
+	if err := startRecovered(&r, job.Handler); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, r.Result())
+}
+
+func (a *Agent) acquire() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.busy {
+		return false
+	}
+	a.busy = true
+	return true
+}
+
+func (a *Agent) release() {
+	a.mu.Lock()
+	a.busy = false
+	a.mu.Unlock()
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		fmt.Println("Error writing response:", err)
+	}
+}
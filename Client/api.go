@@ -1,7 +1,9 @@
 package Client
 
 import (
+	"context"
 	"github.com/jffp113/Thesis_Client/conf"
+	"math/rand"
 	"time"
 )
 
@@ -11,6 +13,12 @@ type RequestStatus struct {
 	StartTime time.Time
 	EndTime   time.Time
 	Success   bool
+	//Err is the error that made the request fail,
+	//it is used to decide if the request is retried
+	Err error
+	//Node is the address of the node that served the request,
+	//it is used to break down the stats per node
+	Node string
 }
 
 type StatusPrinter interface {
@@ -23,10 +31,48 @@ type Handler interface {
 	//DoRequest Should send a request to the system under test
 	//and return the result of a single execution
 	//DoRequest Should be reentrant
-	DoRequest() RequestStatus
+	//Every random choice, such as the node the request is sent to,
+	//should be drawn from rnd which belongs to the calling worker
+	DoRequest(rnd *rand.Rand) RequestStatus
 
 	//InitHandler is executed a single time
 	//The Handler should init everything
-	//needed here
+	//needed here, random choices should use NewStream(config.Seed)
 	InitHandler(config conf.Configuration)
 }
+
+//Resetter can be implemented by handlers that keep state
+//from InitHandler which must be cleared before they are
+//initialized again, for example between repeated runs
+type Resetter interface {
+	Reset()
+}
+
+//Preflighter can be implemented by handlers to check the config
+//and that the system under test is ready before a run, for example
+//that every node answers and that keys load. It must not generate load.
+type Preflighter interface {
+	Preflight(config conf.Configuration) []CheckResult
+}
+
+//ConfigValidator can be implemented by handlers to report the
+//settings they read that cannot work, such as t larger than n,
+//before InitHandler is called with them
+type ConfigValidator interface {
+	ValidateConfig(config conf.Configuration) []conf.Problem
+}
+
+//Reconfigurable can be implemented by handlers that apply a reloaded
+//configuration while requests are sent, such as added or removed nodes.
+//Only the ReloadKeys of the handler differ from the configuration it
+//runs with. Reconfigure is called concurrently with DoRequest, on error
+//the handler must keep its previous configuration.
+type Reconfigurable interface {
+	Reconfigure(config conf.Configuration) error
+}
+
+//LiveView shows the live stats of a run, Run is started with
+//each run and must return once ctx is cancelled
+type LiveView interface {
+	Run(ctx context.Context)
+}
@@ -0,0 +1,140 @@
+package Client
+
+import (
+	"github.com/jffp113/Thesis_Client/Client/util"
+	"math/rand"
+	"time"
+)
+
+//CompareOptions configures how two results are compared
+type CompareOptions struct {
+	//Percentiles of the latency that are compared (0 to 100)
+	Percentiles []float64
+	//Threshold is the relative change (e.g. 0.05) above which a
+	//significant change for the worse is a regression
+	Threshold float64
+	//Alpha is the significance level of the tests
+	Alpha float64
+	//Iterations of the bootstrap used for the confidence intervals
+	Iterations int
+	Seed       int64
+}
+
+func DefaultCompareOptions() CompareOptions {
+	return CompareOptions{
+		Percentiles: []float64{50, 90, 99},
+		Threshold:   0.05,
+		Alpha:       0.05,
+		Iterations:  500,
+		Seed:        1,
+	}
+}
+
+//Comparison of one metric between an old and a new result
+type Comparison struct {
+	Metric string
+	Unit   string
+	Old    float64
+	New    float64
+	//Delta is the relative change, Low and High bound its confidence interval
+	Delta float64
+	Low   float64
+	High  float64
+	//P is the p-value of the Mann-Whitney U test
+	P              float64
+	HigherIsBetter bool
+}
+
+//Significant reports if the change is statistically significant
+func (c Comparison) Significant(alpha float64) bool {
+	return c.P < alpha && (c.Low > 0 || c.High < 0)
+}
+
+//Regression reports a significant change for the worse beyond the threshold
+func (c Comparison) Regression(o CompareOptions) bool {
+	if !c.Significant(o.Alpha) {
+		return false
+	}
+	if c.HigherIsBetter {
+		return c.Delta < -o.Threshold
+	}
+	return c.Delta > o.Threshold
+}
+
+//Improvement reports a significant change for the better beyond the threshold
+func (c Comparison) Improvement(o CompareOptions) bool {
+	if !c.Significant(o.Alpha) {
+		return false
+	}
+	if c.HigherIsBetter {
+		return c.Delta > o.Threshold
+	}
+	return c.Delta < -o.Threshold
+}
+
+//Compare computes throughput and latency percentile deltas between
+//two results. Throughput is compared over the per second series and
+//latencies over the samples kept of the successful requests, the
+//percentiles are the ones the other commands show.
+func Compare(before, after Stats, o CompareOptions) []Comparison {
+	rnd := rand.New(rand.NewSource(o.Seed))
+	var comparisons []Comparison
+
+	beforeTput := trimSeries(before.ThroughputSeries())
+	afterTput := trimSeries(after.ThroughputSeries())
+	c := compareSets("throughput", "req/s", beforeTput, afterTput,
+		util.Mean(beforeTput), util.Mean(afterTput), true)
+	c.Low, c.High = util.BootstrapRatio(beforeTput, afterTput, util.Mean, o.Iterations, 1-o.Alpha, rnd)
+	comparisons = append(comparisons, c)
+
+	beforeLat := millis(before.SampledLatencies())
+	afterLat := millis(after.SampledLatencies())
+	for _, p := range o.Percentiles {
+		c := compareSets(percentileName(p), "ms", beforeLat, afterLat,
+			ms(before.Latency.Percentile(p)), ms(after.Latency.Percentile(p)), false)
+		c.Low, c.High = util.BootstrapPercentileRatio(beforeLat, afterLat, p, o.Iterations, 1-o.Alpha, rnd)
+		comparisons = append(comparisons, c)
+	}
+
+	return comparisons
+}
+
+//compareSets compares the values of the metric, the sets are tested
+//for a shift and the confidence interval of the delta is left to the caller
+func compareSets(metric, unit string, before, after []float64, beforeValue, afterValue float64,
+	higherIsBetter bool) Comparison {
+
+	c := Comparison{
+		Metric:         metric,
+		Unit:           unit,
+		Old:            beforeValue,
+		New:            afterValue,
+		P:              util.MannWhitney(before, after),
+		HigherIsBetter: higherIsBetter,
+	}
+	if c.Old != 0 {
+		c.Delta = c.New/c.Old - 1
+	}
+	return c
+}
+
+//trimSeries drops the first and last seconds of a series because
+//they are usually only partially filled
+func trimSeries(series []float64) []float64 {
+	if len(series) > 2 {
+		return series[1 : len(series)-1]
+	}
+	return series
+}
+
+func millis(latencies []time.Duration) []float64 {
+	values := make([]float64, len(latencies))
+	for i, l := range latencies {
+		values[i] = ms(l)
+	}
+	return values
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
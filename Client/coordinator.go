@@ -0,0 +1,199 @@
+package Client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jffp113/Thesis_Client/conf"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	//clockProbes is the number of round trips used to estimate
+	//the clock offset of an agent, the fastest one is kept
+	clockProbes       = 5
+	defaultStartDelay = 5 * time.Second
+)
+
+//Coordinator runs a job on several agents at the same time and
+//merges their results
+type Coordinator struct {
+	agents     []string
+	startDelay time.Duration
+	client     *http.Client
+	token      string
+	global     *GlobalOptions
+}
+
+//NewCoordinator creates a coordinator of the agents at the given
+//addresses (host:port or URLs)
+func NewCoordinator(agents []string) *Coordinator {
+	return &Coordinator{agents: agents, startDelay: defaultStartDelay, client: &http.Client{}}
+}
+
+//SetStartDelay sets the time between sending the jobs and the
+//start of the workers, it must cover the setup of every agent
+func (c *Coordinator) SetStartDelay(delay time.Duration) {
+	c.startDelay = delay
+}
+
+//SetGlobalOptions keeps the global options of the command
+//so that they are recorded in the result metadata
+func (c *Coordinator) SetGlobalOptions(options GlobalOptions) {
+	c.global = &options
+}
+
+//SetToken sets the token sent to the agents
+func (c *Coordinator) SetToken(token string) {
+	c.token = token
+}
+
+//Run sends the job to every agent with a common start time and
+//merges their results, configData is the config file pushed to them
+//with the profile and overrides of the job applied.
+//The seed of each agent is derived from the seed of the job.
+func (c *Coordinator) Run(ctx context.Context, job AgentJob, configData []byte) (Result, error) {
+	config, err := conf.LoadProfile(configData, job.Profile, job.Overrides)
+	if err != nil {
+		return Result{}, err
+	}
+	job.Config = configData
+	if job.Seed == 0 {
+		job.Seed = config.Seed
+	}
+	if job.Seed == 0 {
+		job.Seed = time.Now().UnixNano()
+	}
+	config.Seed = job.Seed
+
+	offsets := make([]time.Duration, len(c.agents))
+	for i, agent := range c.agents {
+		offsets[i], err = c.clockOffset(ctx, agent)
+		if err != nil {
+			return Result{}, fmt.Errorf("agent %v: %v", agent, err)
+		}
+		fmt.Printf("Agent %v clock offset %v\n", agent, offsets[i])
+	}
+
+	startAt := time.Now().Add(c.startDelay)
+	fmt.Printf("Starting %v agents at %v\n", len(c.agents), startAt.Format(time.RFC3339Nano))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]Result, len(c.agents))
+	errs := make([]error, len(c.agents))
+	var wg sync.WaitGroup
+	for i, agent := range c.agents {
+		agentJob := job
+		agentJob.StartAt = startAt.Add(offsets[i])
+		agentJob.Seed = NewStream(job.Seed, int64(i)).Int63()
+
+		wg.Add(1)
+		go func(i int, agent string) {
+			defer wg.Done()
+			results[i], errs[i] = c.runJob(ctx, agent, agentJob)
+			if errs[i] != nil {
+				//the merged result is useless without every agent
+				cancel()
+			}
+		}(i, agent)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return Result{}, fmt.Errorf("agent %v: %v", c.agents[i], err)
+		}
+	}
+
+	sources := make([]Source, len(c.agents))
+	for i, agent := range c.agents {
+		sources[i] = Source{Name: agent, ClockOffset: offsets[i]}
+	}
+	merged, err := MergeResults(results, sources)
+	if err != nil {
+		return Result{}, err
+	}
+
+	metadata := newRunMetadata(job.Handler, job.Options, config)
+	metadata.Concurrency = merged.Metadata.Concurrency
+	metadata.Duration = job.Duration
+	metadata.StartTime = startAt
+	metadata.Global = c.global
+	merged.Metadata = metadata
+	return merged, nil
+}
+
+//clockOffset estimates how far the clock of the agent is ahead of
+//the local clock, assuming the request and response take as long
+func (c *Coordinator) clockOffset(ctx context.Context, agent string) (time.Duration, error) {
+	var offset time.Duration
+	bestRTT := time.Duration(-1)
+
+	for i := 0; i < clockProbes; i++ {
+		var clock clockResponse
+		sent := time.Now()
+		if err := c.call(ctx, http.MethodGet, agent, "/clock", nil, &clock); err != nil {
+			return 0, err
+		}
+		received := time.Now()
+
+		rtt := received.Sub(sent)
+		if bestRTT < 0 || rtt < bestRTT {
+			bestRTT = rtt
+			offset = clock.Time.Sub(sent.Add(rtt / 2))
+		}
+	}
+	return offset, nil
+}
+
+func (c *Coordinator) runJob(ctx context.Context, agent string, job AgentJob) (Result, error) {
+	var result Result
+	err := c.call(ctx, http.MethodPost, agent, "/run", job, &result)
+	return result, err
+}
+
+func (c *Coordinator) call(ctx context.Context, method, agent, path string, body interface{}, out interface{}) error {
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, agentURL(agent)+path, &reqBody)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", bearerPrefix+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%v: %v", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func agentURL(agent string) string {
+	agent = strings.TrimSuffix(agent, "/")
+	if strings.HasPrefix(agent, "http://") || strings.HasPrefix(agent, "https://") {
+		return agent
+	}
+	return "http://" + agent
+}
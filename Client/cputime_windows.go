@@ -0,0 +1,10 @@
+// +build windows
+
+package Client
+
+import "time"
+
+//processCPUTime is not available on windows, CPU usage is not reported
+func processCPUTime() (time.Duration, bool) {
+	return 0, false
+}
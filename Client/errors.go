@@ -0,0 +1,73 @@
+package Client
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+)
+
+//ErrorClass groups request errors so that they can be
+//counted and retried by category
+type ErrorClass string
+
+const (
+	ErrClassTimeout    ErrorClass = "timeout"
+	ErrClassConnection ErrorClass = "connection"
+	ErrClassRejected   ErrorClass = "rejected"
+	ErrClassPoolFull   ErrorClass = "pool"
+	ErrClassOther      ErrorClass = "other"
+)
+
+type classifiedError struct {
+	class ErrorClass
+	err   error
+}
+
+func (e classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e classifiedError) Unwrap() error {
+	return e.err
+}
+
+//NewClassifiedError tags err with a class so that ClassOf
+//does not have to guess it
+func NewClassifiedError(class ErrorClass, err error) error {
+	if err == nil {
+		return nil
+	}
+	return classifiedError{class: class, err: err}
+}
+
+//ClassOf returns the class of an error returned by a handler.
+//Errors tagged with NewClassifiedError keep their class, network
+//errors are recognized by type and anything else is ErrClassOther
+func ClassOf(err error) ErrorClass {
+	if err == nil {
+		return ""
+	}
+
+	var ce classifiedError
+	if errors.As(err, &ce) {
+		return ce.class
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrClassTimeout
+	}
+
+	var opErr *net.OpError
+	var urlErr *url.Error
+	if errors.As(err, &opErr) || errors.As(err, &urlErr) {
+		return ErrClassConnection
+	}
+
+	if strings.Contains(err.Error(), "Failed to connect") {
+		return ErrClassConnection
+	}
+
+	return ErrClassOther
+}
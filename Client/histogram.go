@@ -0,0 +1,107 @@
+package Client
+
+import (
+	"github.com/jffp113/Thesis_Client/Client/util"
+	"math/bits"
+	"sort"
+	"time"
+)
+
+//histogramSubBuckets is the number of buckets of every power of two,
+//a latency is kept within 1/histogramSubBuckets of its value
+const histogramSubBuckets = 128
+
+//LatencyHistogram counts latencies in buckets whose width grows with
+//the latency, it keeps the distribution of a run of any length in a
+//bounded size and the histograms of several workers or runs add up
+type LatencyHistogram struct {
+	Buckets map[int]int64 `json:"buckets,omitempty"`
+	Count   int64         `json:"count"`
+	Sum     time.Duration `json:"sum"`
+}
+
+//HistogramBucket is a latency and the number of requests that took it
+type HistogramBucket struct {
+	Latency time.Duration
+	Count   int64
+}
+
+func (h *LatencyHistogram) record(latency time.Duration) {
+	if h.Buckets == nil {
+		h.Buckets = make(map[int]int64)
+	}
+	h.Buckets[bucketOf(latency)]++
+	h.Count++
+	h.Sum += latency
+}
+
+func (h *LatencyHistogram) add(o LatencyHistogram) {
+	if len(o.Buckets) > 0 && h.Buckets == nil {
+		h.Buckets = make(map[int]int64)
+	}
+	for bucket, n := range o.Buckets {
+		h.Buckets[bucket] += n
+	}
+	h.Count += o.Count
+	h.Sum += o.Sum
+}
+
+//Mean latency, 0 if there are none
+func (h LatencyHistogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+//Percentile returns the p percentile (0 to 100) using the nearest
+//rank method, as PercentileOf does for sorted latencies
+func (h LatencyHistogram) Percentile(p float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := int64(util.NearestRank(int(h.Count), p))
+
+	var seen int64
+	buckets := h.Sorted()
+	for _, b := range buckets {
+		seen += b.Count
+		if seen > rank {
+			return b.Latency
+		}
+	}
+	return buckets[len(buckets)-1].Latency
+}
+
+//Sorted returns the buckets that hold requests by increasing latency
+func (h LatencyHistogram) Sorted() []HistogramBucket {
+	buckets := make([]HistogramBucket, 0, len(h.Buckets))
+	for bucket, n := range h.Buckets {
+		buckets = append(buckets, HistogramBucket{Latency: bucketLatency(bucket), Count: n})
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Latency < buckets[j].Latency })
+	return buckets
+}
+
+//bucketOf keeps the latencies below 2*histogramSubBuckets nanoseconds
+//exact and the top bits of the larger ones
+func bucketOf(latency time.Duration) int {
+	if latency < 2*histogramSubBuckets {
+		if latency < 0 {
+			return 0
+		}
+		return int(latency)
+	}
+	shift := bits.Len64(uint64(latency)) - bits.Len64(2*histogramSubBuckets-1)
+	return shift*histogramSubBuckets + int(uint64(latency)>>uint(shift))
+}
+
+//bucketLatency returns the middle of the latencies of the bucket
+func bucketLatency(bucket int) time.Duration {
+	if bucket < 2*histogramSubBuckets {
+		return time.Duration(bucket)
+	}
+	shift := uint(bucket/histogramSubBuckets - 1)
+	top := uint64(bucket%histogramSubBuckets + histogramSubBuckets)
+	return time.Duration(top<<shift + (uint64(1)<<shift)/2)
+}
@@ -0,0 +1,190 @@
+package Client
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	//liveWindow is the span of the rolling percentiles
+	liveWindow = 10 * time.Second
+	//liveRateWindow is the span of the current throughput
+	liveRateWindow = 5 * time.Second
+)
+
+//LiveSnapshot is the state of a run while it is executing
+type LiveSnapshot struct {
+	Running   bool
+	Handler   string
+	Elapsed   time.Duration
+	Remaining time.Duration
+	Requests  int
+	InFlight  int
+	//Throughput over the last seconds of the run
+	Throughput float64
+	//Rolling latency percentiles of the successful requests
+	P50    time.Duration
+	P90    time.Duration
+	P99    time.Duration
+	Errors map[ErrorClass]int
+	Nodes  []LiveNode
+}
+
+//LiveNode is the status of a node during the run
+type LiveNode struct {
+	Node        string
+	Requests    int
+	Errors      int
+	LastLatency time.Duration
+	LastError   string
+	//Healthy is false when the last request to the node failed
+	Healthy bool
+}
+
+//liveStats is updated by the workers on every request
+type liveStats struct {
+	mu       sync.Mutex
+	running  bool
+	handler  string
+	start    time.Time
+	duration time.Duration
+	requests int
+	inFlight int
+	errors   map[ErrorClass]int
+	window   []Sample
+	nodes    map[string]*LiveNode
+}
+
+func newLiveStats() *liveStats {
+	return &liveStats{errors: make(map[ErrorClass]int), nodes: make(map[string]*LiveNode)}
+}
+
+func (l *liveStats) reset(handler string, duration time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.running = true
+	l.handler = handler
+	l.start = time.Now()
+	l.duration = duration
+	l.requests = 0
+	l.inFlight = 0
+	l.errors = make(map[ErrorClass]int)
+	l.window = nil
+	l.nodes = make(map[string]*LiveNode)
+}
+
+func (l *liveStats) finish() {
+	l.mu.Lock()
+	l.running = false
+	l.mu.Unlock()
+}
+
+func (l *liveStats) begin() {
+	l.mu.Lock()
+	l.inFlight++
+	l.mu.Unlock()
+}
+
+func (l *liveStats) record(sample Sample, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	l.requests++
+	if !sample.Success {
+		l.errors[sample.ErrClass]++
+	}
+
+	l.window = append(l.window, sample)
+	l.prune(time.Now())
+
+	//requests without a known node are grouped under "-" as in PerNode
+	name := sample.Node
+	if name == "" {
+		name = "-"
+	}
+	node, ok := l.nodes[name]
+	if !ok {
+		node = &LiveNode{Node: name}
+		l.nodes[name] = node
+	}
+	node.Requests++
+	node.LastLatency = sample.Latency
+	node.Healthy = sample.Success
+	if !sample.Success {
+		node.Errors++
+		if err != nil {
+			node.LastError = err.Error()
+		}
+	}
+}
+
+//prune drops the samples that left the rolling window
+func (l *liveStats) prune(now time.Time) {
+	cutoff := now.Add(-liveWindow).UnixNano()
+	i := 0
+	for i < len(l.window) && l.window[i].End() < cutoff {
+		i++
+	}
+	l.window = l.window[i:]
+}
+
+func (l *liveStats) snapshot() LiveSnapshot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.prune(now)
+
+	s := LiveSnapshot{
+		Running:  l.running,
+		Handler:  l.handler,
+		Requests: l.requests,
+		InFlight: l.inFlight,
+		Errors:   make(map[ErrorClass]int),
+	}
+	if l.start.IsZero() {
+		return s
+	}
+
+	s.Elapsed = now.Sub(l.start)
+	if s.Remaining = l.duration - s.Elapsed; s.Remaining < 0 {
+		s.Remaining = 0
+	}
+	for class, n := range l.errors {
+		s.Errors[class] = n
+	}
+
+	rateWindow := liveRateWindow
+	if s.Elapsed < rateWindow {
+		rateWindow = s.Elapsed
+	}
+	rateCutoff := now.Add(-rateWindow).UnixNano()
+	recent := 0
+	var latencies []time.Duration
+	for _, sample := range l.window {
+		if sample.End() >= rateCutoff {
+			recent++
+		}
+		if sample.Success {
+			latencies = append(latencies, sample.Latency)
+		}
+	}
+	if rateWindow > 0 {
+		s.Throughput = float64(recent) / rateWindow.Seconds()
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	s.P50 = PercentileOf(latencies, 50)
+	s.P90 = PercentileOf(latencies, 90)
+	s.P99 = PercentileOf(latencies, 99)
+
+	for _, node := range l.nodes {
+		s.Nodes = append(s.Nodes, *node)
+	}
+	sort.Slice(s.Nodes, func(i, j int) bool { return s.Nodes[i].Node < s.Nodes[j].Node })
+
+	return s
+}
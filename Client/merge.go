@@ -0,0 +1,121 @@
+package Client
+
+import (
+	"fmt"
+	"time"
+)
+
+//Source describes one of the results a merged result was built from
+type Source struct {
+	//Name is the agent address or file the result came from
+	Name  string `json:"name"`
+	RunID string `json:"runId"`
+	//ClockOffset is how far the clock of the source was ahead of the
+	//merging clock, the samples of the source were shifted back by it
+	ClockOffset time.Duration  `json:"clockOffset"`
+	Concurrency int            `json:"concurrency"`
+	NumRequests int            `json:"numRequests"`
+	NumErrs     int            `json:"numErrs"`
+	Throughput  float64        `json:"throughput"`
+	Resources   *ResourceUsage `json:"resources,omitempty"`
+}
+
+//MergeResults combines the results of runs that executed at the same
+//time against the same system into a single result, as if all their
+//workers belonged to one client. The metadata of the first result is
+//kept with the concurrency of all of them.
+func MergeResults(results []Result, sources []Source) (Result, error) {
+	if len(results) == 0 {
+		return Result{}, fmt.Errorf("no results to merge")
+	}
+	if len(sources) != len(results) {
+		return Result{}, fmt.Errorf("%v sources for %v results", len(sources), len(results))
+	}
+
+	seen := make(map[string]bool)
+	for _, r := range results {
+		if seen[r.Metadata.RunID] {
+			return Result{}, fmt.Errorf("run %v is given more than once", r.Metadata.RunID)
+		}
+		seen[r.Metadata.RunID] = true
+	}
+
+	merged := Result{Metadata: results[0].Metadata, Stats: newStats()}
+	merged.Metadata.RunID = newRunID()
+	merged.Metadata.Concurrency = 0
+
+	for i, r := range results {
+		if r.Metadata.Handler != merged.Metadata.Handler {
+			return Result{}, fmt.Errorf("cannot merge results of handlers %v and %v",
+				merged.Metadata.Handler, r.Metadata.Handler)
+		}
+
+		stats := shiftSamples(r.Stats, sources[i].ClockOffset)
+		merged.Stats.add(stats)
+		merged.Stats.ClientsResponses += stats.ClientsResponses
+		merged.Metadata.Concurrency += r.Metadata.Concurrency
+
+		start := r.Metadata.StartTime.Add(-sources[i].ClockOffset)
+		if start.Before(merged.Metadata.StartTime) {
+			merged.Metadata.StartTime = start
+		}
+		if r.Metadata.Duration > merged.Metadata.Duration {
+			merged.Metadata.Duration = r.Metadata.Duration
+		}
+
+		source := sources[i]
+		source.RunID = r.Metadata.RunID
+		source.Concurrency = r.Metadata.Concurrency
+		source.NumRequests = r.Stats.NumRequests
+		source.NumErrs = r.Stats.NumErrs
+		source.Throughput = r.Stats.Throughput()
+		source.Resources = r.Resources
+		merged.Sources = append(merged.Sources, source)
+	}
+
+	return merged, nil
+}
+
+//Overlap returns the share of the shortest source window during
+//which every source was sending requests, merged throughput is only
+//meaningful when the runs overlapped
+func Overlap(results []Result, sources []Source) float64 {
+	var latestStart, earliestEnd time.Time
+	shortest := time.Duration(-1)
+	for i, r := range results {
+		start, end := shiftSamples(r.Stats, sources[i].ClockOffset).Window()
+		if start.IsZero() {
+			continue
+		}
+		if latestStart.IsZero() || start.After(latestStart) {
+			latestStart = start
+		}
+		if earliestEnd.IsZero() || end.Before(earliestEnd) {
+			earliestEnd = end
+		}
+		if window := end.Sub(start); shortest < 0 || window < shortest {
+			shortest = window
+		}
+	}
+
+	if shortest <= 0 || !earliestEnd.After(latestStart) {
+		return 0
+	}
+	return float64(earliestEnd.Sub(latestStart)) / float64(shortest)
+}
+
+//shiftSamples moves the samples and the timeline of the stats
+//back by offset
+func shiftSamples(s Stats, offset time.Duration) Stats {
+	if offset == 0 {
+		return s
+	}
+	s.Timeline = s.Timeline.shift(offset)
+	samples := make([]Sample, len(s.Samples))
+	for i, sample := range s.Samples {
+		sample.Start -= int64(offset)
+		samples[i] = sample
+	}
+	s.Samples = samples
+	return s
+}
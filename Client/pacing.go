@@ -0,0 +1,147 @@
+package Client
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jffp113/Thesis_Client/conf"
+)
+
+//thinkTimer gives the pause a worker takes between requests
+type thinkTimer interface {
+	Next(rnd *rand.Rand) time.Duration
+}
+
+type noThinkTime struct{}
+
+func (noThinkTime) Next(*rand.Rand) time.Duration {
+	return 0
+}
+
+type constantThinkTime time.Duration
+
+func (c constantThinkTime) Next(*rand.Rand) time.Duration {
+	return time.Duration(c)
+}
+
+type uniformThinkTime struct {
+	min time.Duration
+	max time.Duration
+}
+
+func (u uniformThinkTime) Next(rnd *rand.Rand) time.Duration {
+	return u.min + time.Duration(rnd.Int63n(int64(u.max-u.min)+1))
+}
+
+type exponentialThinkTime time.Duration
+
+func (e exponentialThinkTime) Next(rnd *rand.Rand) time.Duration {
+	return time.Duration(rnd.ExpFloat64() * float64(e))
+}
+
+//replayThinkTime draws pauses from the values of a distribution file
+type replayThinkTime []time.Duration
+
+func (r replayThinkTime) Next(rnd *rand.Rand) time.Duration {
+	return r[rnd.Intn(len(r))]
+}
+
+func newThinkTimer(c conf.ThinkTime) (thinkTimer, error) {
+	switch c.Distribution {
+	case "", "none":
+		return noThinkTime{}, nil
+	case "constant":
+		return constantThinkTime(c.Value), nil
+	case "uniform":
+		if c.Max < c.Min {
+			return nil, fmt.Errorf("uniform think time max (%v) is lower than min (%v)", c.Max, c.Min)
+		}
+		return uniformThinkTime{min: c.Min, max: c.Max}, nil
+	case "exponential":
+		return exponentialThinkTime(c.Value), nil
+	case "file":
+		return loadThinkTimeFile(c.File)
+	default:
+		return nil, fmt.Errorf("unknown think time distribution %q", c.Distribution)
+	}
+}
+
+//loadThinkTimeFile reads one duration per line (e.g. 150ms),
+//empty lines and lines starting with # are ignored
+func loadThinkTimeFile(path string) (thinkTimer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var values replayThinkTime
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		d, err := time.ParseDuration(text)
+		if err != nil {
+			return nil, fmt.Errorf("%v:%v: %v", path, line, err)
+		}
+		values = append(values, d)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("think time file %v has no values", path)
+	}
+	return values, nil
+}
+
+//sharedRate is the rate limit of the workers, it is changed
+//when the configuration is reloaded while they run
+type sharedRate struct {
+	bits uint64
+}
+
+func newSharedRate(rate float64) *sharedRate {
+	s := &sharedRate{}
+	s.store(rate)
+	return s
+}
+
+func (s *sharedRate) load() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&s.bits))
+}
+
+func (s *sharedRate) store(rate float64) {
+	atomic.StoreUint64(&s.bits, math.Float64bits(rate))
+}
+
+//pacer decides how long a worker waits before its next request
+type pacer struct {
+	thinkTime thinkTimer
+	rate      *sharedRate
+	rnd       *rand.Rand
+}
+
+//pause returns the wait after a request that started at requestStart.
+//The think time is always respected and, if a rate is set, the wait is
+//stretched so that requests do not start more often than the rate.
+func (p pacer) pause(requestStart time.Time) time.Duration {
+	pause := p.thinkTime.Next(p.rnd)
+
+	if rate := p.rate.load(); rate > 0 {
+		interval := time.Duration(float64(time.Second) / rate)
+		if wait := interval - time.Since(requestStart); wait > pause {
+			pause = wait
+		}
+	}
+	return pause
+}
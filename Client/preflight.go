@@ -0,0 +1,156 @@
+package Client
+
+import (
+	"fmt"
+	"github.com/jffp113/Thesis_Client/conf"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+//preflightTimeout bounds every network check
+const preflightTimeout = 3 * time.Second
+
+var errorClasses = []ErrorClass{ErrClassTimeout, ErrClassConnection, ErrClassRejected, ErrClassPoolFull, ErrClassOther}
+
+//CheckResult is the outcome of a single preflight check
+type CheckResult struct {
+	Name string
+	//Detail tells what was found when the check passed
+	Detail string
+	Err    error
+}
+
+func (c CheckResult) Passed() bool {
+	return c.Err == nil
+}
+
+//NewCheck builds the result of a check that failed if err is not nil
+func NewCheck(name string, detail string, err error) CheckResult {
+	return CheckResult{Name: name, Detail: detail, Err: err}
+}
+
+//Preflight checks the config of a handler and the system under test
+//without generating load, the handler checks run if it implements
+//Preflighter
+func Preflight(handlerName string, c conf.Configuration) ([]CheckResult, error) {
+	info, err := LookupHandler(handlerName)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := info.New()
+	results := configChecks(handlerName, handler, c)
+
+	preflighter, ok := handler.(Preflighter)
+	if !ok {
+		return append(results, NewCheck("handler", "no preflight checks for "+handlerName, nil)), nil
+	}
+	return append(results, preflighter.Preflight(c)...), nil
+}
+
+//configChecks report the problems of the configuration and check
+//the settings shared by every handler
+func configChecks(handlerName string, handler Handler, c conf.Configuration) []CheckResult {
+	var results []CheckResult
+	problems := validateConfig(handlerName, handler, c)
+	for _, p := range problems {
+		results = append(results, NewCheck(p.Path, "", fmt.Errorf("%v", p.Message)))
+	}
+	if len(problems) == 0 {
+		results = append(results, NewCheck("config", "no problems found", nil))
+	}
+
+	if t := c.Pacing.ThinkTime; t.Distribution == "file" && t.File != "" {
+		_, err := newThinkTimer(t)
+		results = append(results, NewCheck("pacing", "think time file "+t.File+" loads", err))
+	}
+
+	policy := c.Retry[handlerName]
+	return append(results, NewCheck("retry", fmt.Sprintf("%v attempts per request", policy.Attempts()), nil))
+}
+
+//validateConfig returns the problems of the shared settings, of the
+//retry policy of the handler and of the settings the handler reads
+func validateConfig(handlerName string, handler Handler, c conf.Configuration) []conf.Problem {
+	problems := c.Validate()
+
+	for i, class := range c.Retry[handlerName].RetryOn {
+		if !knownErrorClass(class) {
+			problems = append(problems, conf.Problemf(fmt.Sprintf("retry.%v.retryOn[%v]", handlerName, i),
+				"unknown error class %q, known: %v", class, errorClasses))
+		}
+	}
+
+	//the checks of the handler need the secrets it reads
+	if info, err := LookupHandler(handlerName); err == nil {
+		if secretProblems := c.SecretProblems(info.ConfigKeys); len(secretProblems) > 0 {
+			return append(problems, secretProblems...)
+		}
+	}
+
+	if validator, ok := handler.(ConfigValidator); ok {
+		problems = append(problems, validator.ValidateConfig(c)...)
+	}
+	return problems
+}
+
+func knownErrorClass(class string) bool {
+	for _, known := range errorClasses {
+		if string(known) == class {
+			return true
+		}
+	}
+	return false
+}
+
+//CheckDial checks that a TCP connection to the node can be opened,
+//address is host:port or a URL
+func CheckDial(name string, address string) CheckResult {
+	host := address
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	host = strings.SplitN(host, "/", 2)[0]
+
+	conn, err := net.DialTimeout("tcp", host, preflightTimeout)
+	if err != nil {
+		return NewCheck(name, "", err)
+	}
+	conn.Close()
+	return NewCheck(name, host+" accepts connections", nil)
+}
+
+//CheckHTTP checks that the URL answers a GET with a status below 500
+func CheckHTTP(name string, url string) CheckResult {
+	client := http.Client{Timeout: preflightTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return NewCheck(name, "", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return NewCheck(name, "", fmt.Errorf("%v answered %v", url, resp.Status))
+	}
+	return NewCheck(name, fmt.Sprintf("%v answered %v", url, resp.Status), nil)
+}
+
+//PrintChecks prints a line per check and returns the number of failures
+func PrintChecks(results []CheckResult) int {
+	failed := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, r := range results {
+		if r.Passed() {
+			fmt.Fprintf(w, "PASS\t%v\t%v\n", r.Name, r.Detail)
+		} else {
+			failed++
+			fmt.Fprintf(w, "FAIL\t%v\t%v\n", r.Name, r.Err)
+		}
+	}
+	w.Flush()
+	return failed
+}
@@ -1,23 +1,154 @@
 package Client
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
-	"time"
+	"os"
+	"sort"
+	"strconv"
 )
 
 type DefaultPrinter struct {
 }
 
 func (DefaultPrinter) Print(stats Stats) {
-	avgDur := stats.TotDuration / time.Duration(stats.ClientsResponses)
-
-	throughput := float64(stats.NumRequests) / avgDur.Seconds()
-	avgLatency := stats.TotDuration / time.Duration(stats.NumRequests)
-
-	fmt.Printf("%v requests in %v\n", stats.NumRequests, avgDur)
-	fmt.Printf("Transactions/sec:\t\t%.2f s\n", throughput)
-	fmt.Printf("Avg Latency:\t\t\t%v\n", avgLatency)
+	fmt.Printf("%v requests in %v\n", stats.NumRequests, stats.AvgDuration())
+	fmt.Printf("Transactions/sec:\t\t%.2f s\n", stats.Throughput())
+	if stats.TotElapsed > 0 {
+		fmt.Printf("Offered Load:\t\t\t%.2f req/s\n", stats.OfferedLoad())
+	}
+	if stats.NumRequests > 0 {
+		fmt.Printf("Avg Think Time:\t\t\t%v\n", stats.AvgThinkTime())
+	}
+	fmt.Printf("Avg Latency:\t\t\t%v\n", stats.AvgLatency())
 	fmt.Printf("Min Transactiom Latency:\t%v\n", stats.MinRequestTime)
 	fmt.Printf("Max Transactiom Latency:\t%v\n", stats.MaxRequestTime)
+	if stats.Latency.Count > 0 {
+		p := stats.Percentiles(50, 90, 99)
+		fmt.Printf("Latency p50/p90/p99:\t\t%v / %v / %v\n", p[0], p[1], p[2])
+	}
 	fmt.Printf("Number of Errors:\t\t%v\n", stats.NumErrs)
+	for _, class := range sortedErrorClasses(stats.Errors) {
+		fmt.Printf("  %v:\t\t\t%v\n", class, stats.Errors[class])
+	}
+	fmt.Printf("First Attempt Successes:\t%v\n", stats.FirstAttemptSuccesses)
+	fmt.Printf("Eventual Successes:\t\t%v\n", stats.EventualSuccesses)
+	fmt.Printf("Number of Retries:\t\t%v\n", stats.NumRetries)
+	fmt.Printf("Time Spent Retrying:\t\t%v\n", stats.RetryDuration)
+}
+
+//PrintRepeatSummary prints the mean and 95% confidence interval
+//of the throughput and latency percentiles of repeated runs
+func PrintRepeatSummary(summary RepeatSummary) {
+	fmt.Printf("Summary of %v repetitions (mean [95%% CI])\n", summary.Repetitions)
+	t := summary.Throughput
+	fmt.Printf("Transactions/sec:\t\t%.2f [%.2f, %.2f]\n", t.Mean, t.Low, t.High)
+	for _, p := range SummaryPercentiles {
+		name := percentileName(p)
+		e := summary.Latency[name]
+		fmt.Printf("Latency %v:\t\t\t%.2f ms [%.2f, %.2f]\n", name, e.Mean, e.Low, e.High)
+	}
+}
+
+//PrintResourceUsage prints what the client used during the run and
+//warns when the client was likely the bottleneck
+func PrintResourceUsage(u ResourceUsage) {
+	if u.CPUAvailable {
+		fmt.Printf("Client CPU avg/max:\t\t%.0f%% / %.0f%% of %v cores\n", u.AvgCPU*100, u.MaxCPU*100, u.Cores)
+	}
+	fmt.Printf("Client Goroutines max:\t\t%v\n", u.MaxGoroutines)
+	fmt.Printf("Client Heap max:\t\t%.1f MiB\n", float64(u.MaxHeapAlloc)/(1<<20))
+	fmt.Printf("Client GC:\t\t\t%v cycles, %v paused (max %v)\n", u.NumGC, u.TotalGCPause, u.MaxGCPause)
+	if u.SaturationHint != "" {
+		fmt.Printf("Warning: %v\n", u.SaturationHint)
+	}
+}
+
+//PrintSources prints the share of each source of a merged result
+func PrintSources(sources []Source) {
+	for _, s := range sources {
+		fmt.Printf("Source %v:\t%v clients, %v requests, %v errors, %.2f tx/sec\n",
+			s.Name, s.Concurrency, s.NumRequests, s.NumErrs, s.Throughput)
+		if s.Resources != nil && s.Resources.SaturationHint != "" {
+			fmt.Printf("Warning: %v: %v\n", s.Name, s.Resources.SaturationHint)
+		}
+	}
+}
+
+//JSONPrinter prints the stats as indented JSON
+type JSONPrinter struct {
+}
+
+func (JSONPrinter) Print(stats Stats) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(stats); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+//CSVPrinter prints a header and a single row with the main
+//numbers, latencies are in milliseconds
+type CSVPrinter struct {
+}
+
+func (CSVPrinter) Print(stats Stats) {
+	w := csv.NewWriter(os.Stdout)
+	p := stats.Percentiles(50, 90, 99)
+	w.Write([]string{"requests", "errors", "throughput", "offered_load",
+		"avg_latency_ms", "min_latency_ms", "max_latency_ms",
+		"p50_latency_ms", "p90_latency_ms", "p99_latency_ms", "retries"})
+	w.Write([]string{
+		strconv.Itoa(stats.NumRequests),
+		strconv.Itoa(stats.NumErrs),
+		strconv.FormatFloat(stats.Throughput(), 'f', 2, 64),
+		strconv.FormatFloat(stats.OfferedLoad(), 'f', 2, 64),
+		formatMillis(stats.AvgLatency().Seconds()),
+		formatMillis(stats.MinRequestTime.Seconds()),
+		formatMillis(stats.MaxRequestTime.Seconds()),
+		formatMillis(p[0].Seconds()),
+		formatMillis(p[1].Seconds()),
+		formatMillis(p[2].Seconds()),
+		strconv.Itoa(stats.NumRetries),
+	})
+	w.Flush()
+}
+
+func formatMillis(seconds float64) string {
+	return strconv.FormatFloat(seconds*1000, 'f', 3, 64)
+}
+
+var printers = map[string]StatusPrinter{
+	"default": DefaultPrinter{},
+	"json":    JSONPrinter{},
+	"csv":     CSVPrinter{},
+}
+
+//GetPrinter returns the printer registered with the given name
+func GetPrinter(name string) (StatusPrinter, error) {
+	p, ok := printers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown printer %q, available: %v", name, PrinterNames())
+	}
+	return p, nil
+}
+
+//PrinterNames returns the names accepted by GetPrinter
+func PrinterNames() []string {
+	var names []string
+	for name := range printers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedErrorClasses(errs map[ErrorClass]int) []ErrorClass {
+	var classes []ErrorClass
+	for class := range errs {
+		classes = append(classes, class)
+	}
+	sort.Slice(classes, func(i, j int) bool { return classes[i] < classes[j] })
+	return classes
 }
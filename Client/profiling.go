@@ -0,0 +1,163 @@
+package Client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	rpprof "runtime/pprof"
+	"runtime/trace"
+	"strings"
+	"time"
+)
+
+//ProfileOptions selects the profiles taken of the client, they only
+//cover the measurement window of each run
+type ProfileOptions struct {
+	CPUProfile string
+	MemProfile string
+	Trace      string
+	//PprofAddr is the address of a pprof HTTP listener that is
+	//open while requests are being sent
+	PprofAddr string
+}
+
+func (o ProfileOptions) enabled() bool {
+	return o.CPUProfile != "" || o.MemProfile != "" || o.Trace != "" || o.PprofAddr != ""
+}
+
+//profiler takes the profiles of a single measurement window
+type profiler struct {
+	opts      ProfileOptions
+	dir       string
+	suffix    string
+	cpuFile   *os.File
+	traceFile *os.File
+	server    *http.Server
+	files     []string
+}
+
+//newProfiler places relative profile paths in dir, suffix is added
+//to the file names to tell apart the profiles of repeated runs
+func newProfiler(opts ProfileOptions, dir string, suffix string) *profiler {
+	return &profiler{opts: opts, dir: dir, suffix: suffix}
+}
+
+func (p *profiler) path(name string) string {
+	if p.suffix != "" {
+		ext := filepath.Ext(name)
+		name = strings.TrimSuffix(name, ext) + p.suffix + ext
+	}
+	if filepath.IsAbs(name) || p.dir == "" {
+		return name
+	}
+	return filepath.Join(p.dir, name)
+}
+
+//start starts the profiles, on error the ones already
+//started are stopped so that the next run can start them
+func (p *profiler) start() error {
+	if err := p.startProfiles(); err != nil {
+		p.stopProfiles()
+		return err
+	}
+	return nil
+}
+
+func (p *profiler) startProfiles() error {
+	if p.opts.CPUProfile != "" {
+		path := p.path(p.opts.CPUProfile)
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := rpprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return err
+		}
+		p.cpuFile = f
+		p.files = append(p.files, path)
+	}
+
+	if p.opts.Trace != "" {
+		path := p.path(p.opts.Trace)
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := trace.Start(f); err != nil {
+			f.Close()
+			return err
+		}
+		p.traceFile = f
+		p.files = append(p.files, path)
+	}
+
+	if p.opts.PprofAddr != "" {
+		listener, err := net.Listen("tcp", p.opts.PprofAddr)
+		if err != nil {
+			return err
+		}
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+		p.server = &http.Server{Handler: mux}
+		go p.server.Serve(listener)
+		fmt.Printf("pprof listening on http://%v/debug/pprof/\n", listener.Addr())
+	}
+
+	return nil
+}
+
+//stop ends the profiles and writes the heap profile
+func (p *profiler) stop() error {
+	p.stopProfiles()
+
+	if p.opts.MemProfile != "" {
+		path := p.path(p.opts.MemProfile)
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		runtime.GC()
+		if err := rpprof.WriteHeapProfile(f); err != nil {
+			return err
+		}
+		p.files = append(p.files, path)
+	}
+
+	return nil
+}
+
+//stopProfiles ends the profiles and the pprof server that were started
+func (p *profiler) stopProfiles() {
+	if p.cpuFile != nil {
+		rpprof.StopCPUProfile()
+		p.cpuFile.Close()
+		p.cpuFile = nil
+	}
+
+	if p.traceFile != nil {
+		trace.Stop()
+		p.traceFile.Close()
+		p.traceFile = nil
+	}
+
+	if p.server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		p.server.Shutdown(ctx)
+		cancel()
+		p.server = nil
+	}
+}
@@ -0,0 +1,114 @@
+package Client
+
+import (
+	"fmt"
+	"github.com/jffp113/Thesis_Client/Client/util"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//maxSuggestionDistance is the largest edit distance of a
+//handler name suggested for an unknown one
+const maxSuggestionDistance = 3
+
+//HandlerInfo describes a handler, handler packages register
+//it from their init function
+type HandlerInfo struct {
+	Name        string
+	Description string
+	//ConfigKeys are the keys of the config file the handler reads
+	ConfigKeys []string
+	//ReloadKeys are the keys applied while running by Reconfigure,
+	//see Reconfigurable
+	ReloadKeys []string
+	New        func() Handler
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]HandlerInfo)
+)
+
+//Register makes a handler available by name, it panics if the
+//name is empty or taken since that is a programming error
+func Register(info HandlerInfo) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if info.Name == "" || info.New == nil {
+		panic("handler registered without a name or constructor")
+	}
+	if _, ok := registry[info.Name]; ok {
+		panic(fmt.Sprintf("handler %v registered twice", info.Name))
+	}
+	registry[info.Name] = info
+}
+
+//RegisteredHandlers returns the registered handlers sorted by name
+func RegisteredHandlers() []HandlerInfo {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	infos := make([]HandlerInfo, 0, len(registry))
+	for _, info := range registry {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+//LookupHandler returns the registered handler with the given name,
+//the error of an unknown name suggests the closest names
+func LookupHandler(name string) (HandlerInfo, error) {
+	registryMu.Lock()
+	info, ok := registry[name]
+	registryMu.Unlock()
+	if ok {
+		return info, nil
+	}
+
+	var names []string
+	for _, info := range RegisteredHandlers() {
+		names = append(names, info.Name)
+	}
+
+	msg := fmt.Sprintf("unknown handler %q", name)
+	if suggestions := suggest(name, names); len(suggestions) > 0 {
+		msg += fmt.Sprintf(", did you mean %v?", strings.Join(suggestions, " or "))
+	}
+	return HandlerInfo{}, fmt.Errorf("%v (available: %v)", msg, strings.Join(names, ", "))
+}
+
+//suggest returns the names closest to name, ignoring case
+func suggest(name string, names []string) []string {
+	best := maxSuggestionDistance + 1
+	var suggestions []string
+	for _, candidate := range names {
+		d := util.Levenshtein(strings.ToLower(name), strings.ToLower(candidate))
+		switch {
+		case d < best:
+			best = d
+			suggestions = []string{candidate}
+		case d == best:
+			suggestions = append(suggestions, candidate)
+		}
+	}
+	return suggestions
+}
+
+//resolveHandler returns the handler added under name or creates
+//it from the registry and keeps it in handlers, so that it is
+//reused by the following runs
+func resolveHandler(handlers map[string]Handler, name string) (Handler, error) {
+	if handler, ok := handlers[name]; ok {
+		return handler, nil
+	}
+	info, err := LookupHandler(name)
+	if err != nil {
+		return nil, err
+	}
+	handler := info.New()
+	handlers[name] = handler
+	return handler, nil
+}
@@ -0,0 +1,169 @@
+package Client
+
+import (
+	"context"
+	"fmt"
+	"github.com/jffp113/Thesis_Client/conf"
+	"sync"
+	"time"
+)
+
+//rateKey is the config key the requester applies itself on reload
+const rateKey = "pacing.rate"
+
+//Reload is a reload of the configuration that changed the run
+type Reload struct {
+	Time    time.Time     `json:"time"`
+	Applied []conf.Change `json:"applied"`
+}
+
+//reloader applies the reloaded configurations to a running benchmark,
+//it holds the configuration the handler runs with
+type reloader struct {
+	mu          sync.Mutex
+	handlerName string
+	handler     Handler
+	//keys are the config keys that can change while running
+	keys map[string]bool
+	//otherKeys are the keys read only by the other handlers
+	otherKeys map[string]bool
+	config    conf.Configuration
+	//loaded is the last configuration loaded, the changes that
+	//need a restart are only logged when they first appear
+	loaded  conf.Configuration
+	rate    *sharedRate
+	reloads []Reload
+}
+
+func newReloader(handlerName string, handler Handler, c conf.Configuration) *reloader {
+	keys := map[string]bool{rateKey: true}
+	otherKeys := make(map[string]bool)
+	for _, info := range RegisteredHandlers() {
+		for _, key := range info.ConfigKeys {
+			otherKeys[key] = true
+		}
+	}
+	if info, err := LookupHandler(handlerName); err == nil {
+		for _, key := range info.ConfigKeys {
+			delete(otherKeys, key)
+		}
+		if _, ok := handler.(Reconfigurable); ok {
+			for _, key := range info.ReloadKeys {
+				keys[key] = true
+			}
+		}
+	}
+	return &reloader{
+		handlerName: handlerName,
+		handler:     handler,
+		keys:        keys,
+		otherKeys:   otherKeys,
+		config:      c,
+		loaded:      c,
+		rate:        newSharedRate(c.Pacing.Rate),
+	}
+}
+
+//initHandler initializes the handler with the current configuration
+func (l *reloader) initHandler() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.handler.InitHandler(l.config)
+}
+
+//resetHandler resets the handler, if it is a Resetter
+func (l *reloader) resetHandler() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if resetter, ok := l.handler.(Resetter); ok {
+		resetter.Reset()
+	}
+}
+
+//watch reloads the configuration with load each time trigger
+//fires until ctx is done, the returned function waits for it
+func (l *reloader) watch(ctx context.Context, trigger <-chan struct{}, load func() (conf.Configuration, error)) func() {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-trigger:
+				next, err := load()
+				if err != nil {
+					logReload(time.Now(), "config reload failed: %v", err)
+					continue
+				}
+				l.apply(next)
+			}
+		}
+	}()
+	return func() { <-done }
+}
+
+//apply changes the keys of the configuration that can change while
+//running, the changes of other keys are logged and left out
+func (l *reloader) apply(next conf.Configuration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	next.Seed = l.config.Seed
+	for _, change := range conf.Diff(l.loaded, next) {
+		if !l.keys[change.Key] && !l.otherKeys[change.Key] {
+			logReload(now, "config reload ignored %v, it needs a restart", change)
+		}
+	}
+	l.loaded = next
+
+	var applied []conf.Change
+	var keys []string
+	handlerChanged := false
+	for _, change := range conf.Diff(l.config, next) {
+		if l.keys[change.Key] {
+			applied = append(applied, change)
+			keys = append(keys, change.Key)
+			handlerChanged = handlerChanged || change.Key != rateKey
+		}
+	}
+	if len(applied) == 0 {
+		logReload(now, "config reloaded, no change to apply")
+		return
+	}
+
+	updated := l.config
+	if err := conf.CopyKeys(&updated, next, keys); err != nil {
+		logReload(now, "config reload rejected: %v", err)
+		return
+	}
+	if err := conf.AsError(validateConfig(l.handlerName, l.handler, updated)); err != nil {
+		logReload(now, "config reload rejected: %v", err)
+		return
+	}
+	if handlerChanged {
+		if err := l.handler.(Reconfigurable).Reconfigure(updated); err != nil {
+			logReload(now, "config reload rejected by %v: %v", l.handlerName, err)
+			return
+		}
+	}
+
+	l.rate.store(updated.Pacing.Rate)
+	l.config = updated
+	for _, change := range applied {
+		logReload(now, "config reload applied %v", change)
+	}
+	l.reloads = append(l.reloads, Reload{Time: now, Applied: applied})
+}
+
+//applied returns the reloads that changed the run
+func (l *reloader) applied() []Reload {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.reloads
+}
+
+func logReload(t time.Time, format string, args ...interface{}) {
+	fmt.Printf("%v %v\n", t.Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
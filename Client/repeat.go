@@ -0,0 +1,75 @@
+package Client
+
+import (
+	"github.com/jffp113/Thesis_Client/Client/util"
+	"time"
+)
+
+//Repetition keeps the details of one run of a repeated benchmark,
+//its samples, latencies, nodes and timeline are only kept in the
+//aggregated stats of the result
+type Repetition struct {
+	Stats       Stats                    `json:"stats"`
+	Percentiles map[string]time.Duration `json:"percentiles"`
+}
+
+//Estimate is a mean with its 95% confidence interval
+type Estimate struct {
+	Mean float64 `json:"mean"`
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
+}
+
+//RepeatSummary summarizes the repetitions of a benchmark,
+//latencies are in milliseconds
+type RepeatSummary struct {
+	Repetitions int                 `json:"repetitions"`
+	Throughput  Estimate            `json:"throughput"`
+	Latency     map[string]Estimate `json:"latency"`
+}
+
+//SummaryPercentiles are the latency percentiles summarized
+//over repeated runs
+var SummaryPercentiles = []float64{50, 90, 95, 99}
+
+func newRepetition(stats Stats) Repetition {
+	rep := Repetition{Percentiles: make(map[string]time.Duration)}
+	for i, p := range stats.Percentiles(SummaryPercentiles...) {
+		rep.Percentiles[percentileName(SummaryPercentiles[i])] = p
+	}
+	stats.Samples = nil
+	stats.Latency = LatencyHistogram{}
+	stats.Nodes = nil
+	stats.Timeline = Timeline{}
+	rep.Stats = stats
+	return rep
+}
+
+func summarizeRepetitions(reps []Repetition) *RepeatSummary {
+	summary := RepeatSummary{
+		Repetitions: len(reps),
+		Latency:     make(map[string]Estimate),
+	}
+
+	var throughputs []float64
+	for _, rep := range reps {
+		throughputs = append(throughputs, rep.Stats.Throughput())
+	}
+	summary.Throughput = newEstimate(throughputs)
+
+	for _, p := range SummaryPercentiles {
+		name := percentileName(p)
+		var values []float64
+		for _, rep := range reps {
+			values = append(values, float64(rep.Percentiles[name])/float64(time.Millisecond))
+		}
+		summary.Latency[name] = newEstimate(values)
+	}
+
+	return &summary
+}
+
+func newEstimate(values []float64) Estimate {
+	mean, low, high := util.MeanCI95(values)
+	return Estimate{Mean: mean, Low: low, High: high}
+}
@@ -0,0 +1,262 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jffp113/Thesis_Client/Client"
+	"html/template"
+	"io"
+	"sort"
+	"time"
+)
+
+const (
+	histogramBins = 40
+	maxCDFPoints  = 500
+)
+
+type reportData struct {
+	Title       string
+	Overview    [][2]string
+	Histogram   template.HTML
+	CDF         template.HTML
+	Throughput  template.HTML
+	Errors      template.HTML
+	ErrorRows   []errorRow
+	Nodes       []Client.NodeStats
+	Repetitions []repetitionRow
+	Metadata    string
+}
+
+type errorRow struct {
+	Class Client.ErrorClass
+	Count int
+	Share string
+}
+
+type repetitionRow struct {
+	Index      int
+	Throughput string
+	P50        time.Duration
+	P99        time.Duration
+	Errors     int
+}
+
+//WriteHTML writes a self contained HTML report of the result,
+//charts are inline SVG so the report works offline
+func WriteHTML(w io.Writer, result Client.Result) error {
+	stats := result.Stats
+
+	metadata, err := json.MarshalIndent(result.Metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	data := reportData{
+		Title:       fmt.Sprintf("Run %v - %v", result.Metadata.RunID, result.Metadata.Handler),
+		Overview:    overview(result),
+		Histogram:   histogram(stats.Latency),
+		CDF:         cdf(stats.Latency),
+		Throughput:  throughput(stats),
+		Errors:      errorChart(stats),
+		ErrorRows:   errorRows(stats),
+		Nodes:       stats.PerNode(),
+		Repetitions: repetitionRows(result.Repetitions),
+		Metadata:    string(metadata),
+	}
+
+	return reportTemplate.Execute(w, data)
+}
+
+func overview(result Client.Result) [][2]string {
+	m := result.Metadata
+	s := result.Stats
+	rows := [][2]string{
+		{"Run ID", m.RunID},
+		{"Handler", m.Handler},
+		{"Started", m.StartTime.Format(time.RFC3339)},
+		{"Clients", fmt.Sprint(m.Concurrency)},
+		{"Duration", m.Duration.String()},
+		{"Client version", m.ClientVersion},
+		{"Go version", m.GoVersion},
+		{"CPUs", fmt.Sprint(m.NumCPU)},
+		{"Requests", fmt.Sprint(s.NumRequests)},
+		{"Errors", fmt.Sprint(s.NumErrs)},
+		{"Transactions/sec", fmt.Sprintf("%.2f", s.Throughput())},
+		{"Offered load", fmt.Sprintf("%.2f req/s", s.OfferedLoad())},
+		{"Avg latency", s.AvgLatency().String()},
+	}
+	for _, p := range []float64{50, 90, 99, 99.9} {
+		rows = append(rows, [2]string{fmt.Sprintf("p%v latency", p), s.Latency.Percentile(p).String()})
+	}
+	rows = append(rows,
+		[2]string{"Retries", fmt.Sprint(s.NumRetries)},
+		[2]string{"Eventual successes", fmt.Sprint(s.EventualSuccesses)},
+	)
+	if sum := result.Summary; sum != nil {
+		rows = append(rows, [2]string{"Repetitions", fmt.Sprintf("%v, %.2f tx/s [%.2f, %.2f]",
+			sum.Repetitions, sum.Throughput.Mean, sum.Throughput.Low, sum.Throughput.High)})
+	}
+	return rows
+}
+
+//histogram of the latencies up to p99.9, the outliers above it are
+//dropped so that they do not squash the rest of the distribution
+func histogram(latencies Client.LatencyHistogram) template.HTML {
+	if latencies.Count == 0 {
+		return emptyChart()
+	}
+
+	buckets := latencies.Sorted()
+	min := ms(buckets[0].Latency)
+	max := ms(latencies.Percentile(99.9))
+	if max <= min {
+		max = min + 1
+	}
+	width := (max - min) / histogramBins
+
+	counts := make([]float64, histogramBins)
+	for _, b := range buckets {
+		if ms(b.Latency) > max {
+			continue
+		}
+		bin := int((ms(b.Latency) - min) / width)
+		//p99.9 itself is the upper edge of the last bin
+		if bin >= histogramBins {
+			bin = histogramBins - 1
+		}
+		counts[bin] += float64(b.Count)
+	}
+
+	bars := make([]bar, histogramBins)
+	for i := range counts {
+		bars[i] = bar{Label: formatTick(min + width*float64(i)), Value: counts[i]}
+	}
+	return barChart(bars, "latency (ms)", "requests")
+}
+
+func cdf(latencies Client.LatencyHistogram) template.HTML {
+	if latencies.Count == 0 {
+		return emptyChart()
+	}
+
+	buckets := latencies.Sorted()
+	step := len(buckets)/maxCDFPoints + 1
+	var points []point
+	var seen int64
+	for i, b := range buckets {
+		seen += b.Count
+		if i%step == 0 {
+			points = append(points, point{X: ms(b.Latency), Y: float64(seen) / float64(latencies.Count)})
+		}
+	}
+	points = append(points, point{X: ms(buckets[len(buckets)-1].Latency), Y: 1})
+	return lineChart(points, "latency (ms)", "fraction of requests")
+}
+
+func throughput(stats Client.Stats) template.HTML {
+	var points []point
+	for i, v := range stats.ThroughputSeries() {
+		points = append(points, point{X: float64(i), Y: v})
+	}
+	return lineChart(points, "time (s)", "requests/sec")
+}
+
+func errorChart(stats Client.Stats) template.HTML {
+	var bars []bar
+	for _, row := range errorRows(stats) {
+		bars = append(bars, bar{Label: string(row.Class), Value: float64(row.Count)})
+	}
+	if len(bars) == 0 {
+		return template.HTML(`<p class="empty">No errors</p>`)
+	}
+	return barChart(bars, "error class", "requests")
+}
+
+func errorRows(stats Client.Stats) []errorRow {
+	var rows []errorRow
+	for class, n := range stats.Errors {
+		share := 0.0
+		if stats.NumRequests > 0 {
+			share = float64(n) / float64(stats.NumRequests) * 100
+		}
+		rows = append(rows, errorRow{Class: class, Count: n, Share: fmt.Sprintf("%.2f%%", share)})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Count > rows[j].Count })
+	return rows
+}
+
+func repetitionRows(reps []Client.Repetition) []repetitionRow {
+	var rows []repetitionRow
+	for i, rep := range reps {
+		rows = append(rows, repetitionRow{
+			Index:      i + 1,
+			Throughput: fmt.Sprintf("%.2f", rep.Stats.Throughput()),
+			P50:        rep.Percentiles["p50"],
+			P99:        rep.Percentiles["p99"],
+			Errors:     rep.Stats.NumErrs,
+		})
+	}
+	return rows
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 800px; color: #222; }
+h1 { font-size: 1.5em; }
+h2 { font-size: 1.2em; margin-top: 2em; border-bottom: 1px solid #ccc; }
+table { border-collapse: collapse; }
+td, th { padding: 2px 12px; text-align: left; border-bottom: 1px solid #eee; }
+pre { background: #f6f6f6; padding: 1em; overflow: auto; font-size: 0.85em; }
+.empty { color: #888; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+
+<h2>Overview</h2>
+<table>
+{{range .Overview}}<tr><th>{{index . 0}}</th><td>{{index . 1}}</td></tr>
+{{end}}</table>
+
+<h2>Latency histogram</h2>
+{{.Histogram}}
+
+<h2>Latency CDF</h2>
+{{.CDF}}
+
+<h2>Throughput over time</h2>
+{{.Throughput}}
+
+<h2>Errors</h2>
+{{.Errors}}
+{{if .ErrorRows}}<table>
+<tr><th>Class</th><th>Requests</th><th>Share</th></tr>
+{{range .ErrorRows}}<tr><td>{{.Class}}</td><td>{{.Count}}</td><td>{{.Share}}</td></tr>
+{{end}}</table>{{end}}
+
+<h2>Nodes</h2>
+{{if .Nodes}}<table>
+<tr><th>Node</th><th>Requests</th><th>Errors</th><th>Avg</th><th>p50</th><th>p99</th></tr>
+{{range .Nodes}}<tr><td>{{.Node}}</td><td>{{.Requests}}</td><td>{{.Errors}}</td><td>{{.AvgLatency}}</td><td>{{.P50}}</td><td>{{.P99}}</td></tr>
+{{end}}</table>{{else}}<p class="empty">No samples recorded</p>{{end}}
+
+{{if .Repetitions}}<h2>Repetitions</h2>
+<table>
+<tr><th>#</th><th>Transactions/sec</th><th>p50</th><th>p99</th><th>Errors</th></tr>
+{{range .Repetitions}}<tr><td>{{.Index}}</td><td>{{.Throughput}}</td><td>{{.P50}}</td><td>{{.P99}}</td><td>{{.Errors}}</td></tr>
+{{end}}</table>{{end}}
+
+<h2>Run metadata</h2>
+<pre>{{.Metadata}}</pre>
+</body>
+</html>
+`))
@@ -0,0 +1,157 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"strings"
+)
+
+const (
+	chartWidth  = 720
+	chartHeight = 260
+	marginLeft  = 60
+	marginRight = 20
+	marginTop   = 20
+	marginBot   = 40
+)
+
+//point of a chart in data coordinates
+type point struct {
+	X float64
+	Y float64
+}
+
+//bar of a bar chart, X is the label shown below it
+type bar struct {
+	Label string
+	Value float64
+}
+
+//lineChart draws the points joined by a line with labelled axes
+func lineChart(points []point, xLabel, yLabel string) template.HTML {
+	if len(points) == 0 {
+		return emptyChart()
+	}
+
+	minX, maxX := points[0].X, points[0].X
+	maxY := 0.0
+	for _, p := range points {
+		minX = math.Min(minX, p.X)
+		maxX = math.Max(maxX, p.X)
+		maxY = math.Max(maxY, p.Y)
+	}
+	if maxX == minX {
+		maxX = minX + 1
+	}
+	if maxY == 0 {
+		maxY = 1
+	}
+
+	var b strings.Builder
+	openChart(&b)
+	axes(&b, minX, maxX, 0, maxY, xLabel, yLabel)
+
+	b.WriteString(`<polyline fill="none" stroke="#1f77b4" stroke-width="2" points="`)
+	for _, p := range points {
+		fmt.Fprintf(&b, "%.1f,%.1f ", scaleX(p.X, minX, maxX), scaleY(p.Y, 0, maxY))
+	}
+	b.WriteString(`"/>`)
+
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+//barChart draws one vertical bar per entry
+func barChart(bars []bar, xLabel, yLabel string) template.HTML {
+	if len(bars) == 0 {
+		return emptyChart()
+	}
+
+	maxY := 0.0
+	for _, br := range bars {
+		maxY = math.Max(maxY, br.Value)
+	}
+	if maxY == 0 {
+		maxY = 1
+	}
+
+	var b strings.Builder
+	openChart(&b)
+	axes(&b, 0, 0, 0, maxY, xLabel, yLabel)
+
+	plotWidth := float64(chartWidth - marginLeft - marginRight)
+	slot := plotWidth / float64(len(bars))
+	labelEvery := int(math.Ceil(float64(len(bars)) / 10))
+	for i, br := range bars {
+		x := float64(marginLeft) + float64(i)*slot
+		y := scaleY(br.Value, 0, maxY)
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="#1f77b4"><title>%s: %v</title></rect>`,
+			x+slot*0.1, y, slot*0.8, float64(chartHeight-marginBot)-y, template.HTMLEscapeString(br.Label), br.Value)
+		if i%labelEvery == 0 {
+			fmt.Fprintf(&b, `<text x="%.1f" y="%d" font-size="10" text-anchor="middle">%s</text>`,
+				x+slot/2, chartHeight-marginBot+14, template.HTMLEscapeString(br.Label))
+		}
+	}
+
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+func openChart(b *strings.Builder) {
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif">`,
+		chartWidth, chartHeight, chartWidth, chartHeight)
+}
+
+//axes draws both axes, x ticks are skipped when minX equals maxX
+func axes(b *strings.Builder, minX, maxX, minY, maxY float64, xLabel, yLabel string) {
+	bottom := chartHeight - marginBot
+	right := chartWidth - marginRight
+	fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#333"/>`, marginLeft, bottom, right, bottom)
+	fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#333"/>`, marginLeft, marginTop, marginLeft, bottom)
+
+	for i := 0; i <= 4; i++ {
+		v := minY + (maxY-minY)*float64(i)/4
+		y := scaleY(v, minY, maxY)
+		fmt.Fprintf(b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#ddd"/>`, marginLeft, y, right, y)
+		fmt.Fprintf(b, `<text x="%d" y="%.1f" font-size="10" text-anchor="end">%s</text>`, marginLeft-4, y+3, formatTick(v))
+	}
+
+	if maxX != minX {
+		for i := 0; i <= 5; i++ {
+			v := minX + (maxX-minX)*float64(i)/5
+			fmt.Fprintf(b, `<text x="%.1f" y="%d" font-size="10" text-anchor="middle">%s</text>`,
+				scaleX(v, minX, maxX), bottom+14, formatTick(v))
+		}
+	}
+
+	fmt.Fprintf(b, `<text x="%d" y="%d" font-size="12" text-anchor="middle">%s</text>`,
+		(marginLeft+right)/2, chartHeight-6, template.HTMLEscapeString(xLabel))
+	fmt.Fprintf(b, `<text x="14" y="%d" font-size="12" text-anchor="middle" transform="rotate(-90 14 %d)">%s</text>`,
+		(marginTop+bottom)/2, (marginTop+bottom)/2, template.HTMLEscapeString(yLabel))
+}
+
+func scaleX(v, min, max float64) float64 {
+	return marginLeft + (v-min)/(max-min)*float64(chartWidth-marginLeft-marginRight)
+}
+
+func scaleY(v, min, max float64) float64 {
+	return float64(chartHeight-marginBot) - (v-min)/(max-min)*float64(chartHeight-marginTop-marginBot)
+}
+
+func formatTick(v float64) string {
+	switch {
+	case v == 0:
+		return "0"
+	case math.Abs(v) >= 100:
+		return fmt.Sprintf("%.0f", v)
+	case math.Abs(v) >= 1:
+		return fmt.Sprintf("%.1f", v)
+	default:
+		return fmt.Sprintf("%.3f", v)
+	}
+}
+
+func emptyChart() template.HTML {
+	return template.HTML(`<p class="empty">No samples recorded</p>`)
+}
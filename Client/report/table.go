@@ -0,0 +1,236 @@
+package report
+
+import (
+	"fmt"
+	"github.com/jffp113/Thesis_Client/Client"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Table is a pivot of a set of runs, each cell is the mean of the
+//metric over the runs that share its row and column values
+type Table struct {
+	RowDim  string
+	ColDim  string
+	Metric  string
+	Rows    []string
+	Cols    []string
+	Cells   map[[2]string]float64
+	Samples map[[2]string]int
+}
+
+//Dimensions that can be used as rows or columns
+var Dimensions = []string{"handler", "scheme", "concurrency", "duration", "n", "t", "keyName", "version"}
+
+//Metrics that can be used as cells, pXX is any latency percentile
+var Metrics = []string{"throughput", "offered", "avg", "pXX", "errors", "errorRate", "retries"}
+
+func dimensionValue(r Client.Result, dim string) (string, error) {
+	m := r.Metadata
+	group := m.Config.Group()
+	switch dim {
+	case "handler":
+		return m.Handler, nil
+	case "scheme":
+		return group.Scheme, nil
+	case "concurrency":
+		return strconv.Itoa(m.Concurrency), nil
+	case "duration":
+		return m.Duration.String(), nil
+	case "n":
+		return strconv.Itoa(group.N), nil
+	case "t":
+		return strconv.Itoa(group.T), nil
+	case "keyName":
+		return group.KeyName, nil
+	case "version":
+		return m.ClientVersion, nil
+	default:
+		return "", fmt.Errorf("unknown dimension %q, available: %v", dim, strings.Join(Dimensions, ", "))
+	}
+}
+
+//metricValue returns the metric of a run, latencies are in milliseconds
+func metricValue(r Client.Result, metric string) (float64, error) {
+	s := r.Stats
+	switch {
+	case metric == "throughput":
+		return s.Throughput(), nil
+	case metric == "offered":
+		return s.OfferedLoad(), nil
+	case metric == "avg":
+		return ms(s.AvgLatency()), nil
+	case metric == "errors":
+		return float64(s.NumErrs), nil
+	case metric == "errorRate":
+		if s.NumRequests == 0 {
+			return 0, nil
+		}
+		return float64(s.NumErrs) / float64(s.NumRequests) * 100, nil
+	case metric == "retries":
+		return float64(s.NumRetries), nil
+	case strings.HasPrefix(metric, "p"):
+		p, err := strconv.ParseFloat(metric[1:], 64)
+		if err != nil || p < 0 || p > 100 {
+			return 0, fmt.Errorf("invalid percentile %q", metric)
+		}
+		return ms(s.Percentiles(p)[0]), nil
+	default:
+		return 0, fmt.Errorf("unknown metric %q, available: %v", metric, strings.Join(Metrics, ", "))
+	}
+}
+
+//BuildTable pivots the runs with rowDim and colDim as dimensions
+func BuildTable(results []Client.Result, rowDim, colDim, metric string) (Table, error) {
+	t := Table{
+		RowDim:  rowDim,
+		ColDim:  colDim,
+		Metric:  metric,
+		Cells:   make(map[[2]string]float64),
+		Samples: make(map[[2]string]int),
+	}
+
+	rows := make(map[string]bool)
+	cols := make(map[string]bool)
+	for _, r := range results {
+		row, err := dimensionValue(r, rowDim)
+		if err != nil {
+			return t, err
+		}
+		col, err := dimensionValue(r, colDim)
+		if err != nil {
+			return t, err
+		}
+		v, err := metricValue(r, metric)
+		if err != nil {
+			return t, err
+		}
+
+		key := [2]string{row, col}
+		t.Cells[key] += v
+		t.Samples[key]++
+		rows[row] = true
+		cols[col] = true
+	}
+
+	for key, n := range t.Samples {
+		t.Cells[key] /= float64(n)
+	}
+	t.Rows = sortedValues(rows)
+	t.Cols = sortedValues(cols)
+	return t, nil
+}
+
+//sortedValues sorts numerically when every value is a number
+//or a duration and alphabetically otherwise
+func sortedValues(set map[string]bool) []string {
+	var values []string
+	for v := range set {
+		values = append(values, v)
+	}
+
+	numeric := true
+	nums := make(map[string]float64)
+	for _, v := range values {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			nums[v] = f
+		} else if d, err := time.ParseDuration(v); err == nil {
+			nums[v] = float64(d)
+		} else {
+			numeric = false
+			break
+		}
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		if numeric {
+			return nums[values[i]] < nums[values[j]]
+		}
+		return values[i] < values[j]
+	})
+	return values
+}
+
+func (t Table) cell(row, col string) string {
+	key := [2]string{row, col}
+	if t.Samples[key] == 0 {
+		return "-"
+	}
+	return strconv.FormatFloat(t.Cells[key], 'f', 2, 64)
+}
+
+func (t Table) corner() string {
+	return fmt.Sprintf("%v / %v", t.RowDim, t.ColDim)
+}
+
+//WriteMarkdown writes the table in GitHub flavoured markdown
+func WriteMarkdown(w io.Writer, t Table) error {
+	header := append([]string{t.corner()}, t.Cols...)
+	if _, err := fmt.Fprintf(w, "| %v |\n", strings.Join(header, " | ")); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "|%v\n", strings.Repeat(" --- |", len(header)))
+
+	for _, row := range t.Rows {
+		line := []string{row}
+		for _, col := range t.Cols {
+			line = append(line, t.cell(row, col))
+		}
+		fmt.Fprintf(w, "| %v |\n", strings.Join(line, " | "))
+	}
+	_, err := fmt.Fprintf(w, "\n%v\n", t.caption())
+	return err
+}
+
+//WriteLaTeX writes the table as a LaTeX tabular environment
+func WriteLaTeX(w io.Writer, t Table) error {
+	if _, err := fmt.Fprintf(w, "%% %v\n", t.caption()); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\\begin{tabular}{l%v}\n", strings.Repeat("r", len(t.Cols)))
+	fmt.Fprintln(w, "\\hline")
+
+	header := []string{latexEscape(t.corner())}
+	for _, col := range t.Cols {
+		header = append(header, latexEscape(col))
+	}
+	fmt.Fprintf(w, "%v \\\\\n", strings.Join(header, " & "))
+	fmt.Fprintln(w, "\\hline")
+
+	for _, row := range t.Rows {
+		line := []string{latexEscape(row)}
+		for _, col := range t.Cols {
+			line = append(line, t.cell(row, col))
+		}
+		fmt.Fprintf(w, "%v \\\\\n", strings.Join(line, " & "))
+	}
+
+	fmt.Fprintln(w, "\\hline")
+	_, err := fmt.Fprintln(w, "\\end{tabular}")
+	return err
+}
+
+func (t Table) caption() string {
+	unit := ""
+	switch {
+	case t.Metric == "throughput" || t.Metric == "offered":
+		unit = " (req/s)"
+	case t.Metric == "avg" || (strings.HasPrefix(t.Metric, "p") && t.Metric != "pXX"):
+		unit = " (ms)"
+	case t.Metric == "errorRate":
+		unit = " (%)"
+	}
+	return fmt.Sprintf("%v%v by %v and %v, mean over runs", t.Metric, unit, t.RowDim, t.ColDim)
+}
+
+var latexReplacer = strings.NewReplacer(
+	`\`, `\textbackslash{}`, `&`, `\&`, `%`, `\%`, `$`, `\$`,
+	`#`, `\#`, `_`, `\_`, `{`, `\{`, `}`, `\}`, `~`, `\textasciitilde{}`, `^`, `\textasciicircum{}`,
+)
+
+func latexEscape(s string) string {
+	return latexReplacer.Replace(s)
+}
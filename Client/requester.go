@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"github.com/jffp113/Thesis_Client/Client/util"
 	"github.com/jffp113/Thesis_Client/conf"
+	"math/rand"
+	"path/filepath"
+	"sync"
 	"time"
 )
 
@@ -12,25 +15,50 @@ type requester struct {
 	duration          time.Duration //seconds
 	concurrentClients int
 	configFilePath    string
+	configData        []byte
+	profile           string
+	overrides         []conf.Override
+	config            *conf.Configuration
 	handlers          map[string]Handler
 	ctx               context.Context
 	cancelFunc        context.CancelFunc
 	printer           StatusPrinter
+	runOptions        interface{}
+	globalOptions     *GlobalOptions
+	outputPath        string
+	store             *Store
+	result            Result
+	repetitions       int
+	pause             time.Duration
+	live              *liveStats
+	liveView          LiveView
+	monitor           *resourceMonitor
+	profiling         ProfileOptions
+	startAt           time.Time
+	seed              int64
+	keepSamples       int
+	reloadTrigger     <-chan struct{}
+	reloadConfig      func() (conf.Configuration, error)
+
+	//failure is the error that stopped the current run early
+	failMu  sync.Mutex
+	failure error
 }
 
-type Stats struct {
-	TotDuration      time.Duration
-	MinRequestTime   time.Duration
-	MaxRequestTime   time.Duration
-	NumRequests      int
-	NumErrs          int
-	ClientsResponses int
+//runSetup is what every run of a Start shares
+type runSetup struct {
+	handler     Handler
+	handlerName string
+	config      conf.Configuration
+	thinkTime   thinkTimer
+	reloader    *reloader
 }
 
 func NewRequester() requester {
 	return requester{ctx: context.Background(),
-		printer:  DefaultPrinter{},
-		handlers: make(map[string]Handler)}
+		printer:     DefaultPrinter{},
+		handlers:    make(map[string]Handler),
+		keepSamples: DefaultKeepSamples}
 }
 
 func (r *requester) SetDuration(duration time.Duration) {
@@ -45,6 +73,130 @@ func (r *requester) SetConfigFilePath(path string) {
 	r.configFilePath = path
 }
 
+//SetConfigData sets the contents of a config file received from
+//another machine, it is used instead of the config file path when
+//not empty and the secrets the handler reads cannot be references
+func (r *requester) SetConfigData(data []byte) {
+	r.configData = data
+}
+
+//SetProfile selects the profile of the config file or data
+func (r *requester) SetProfile(profile string) {
+	r.profile = profile
+}
+
+//SetOverrides sets the config keys replaced after the config
+//file or data is parsed
+func (r *requester) SetOverrides(overrides []conf.Override) {
+	r.overrides = overrides
+}
+
+//SetConfig sets the configuration of the runs, no config file is read
+func (r *requester) SetConfig(c conf.Configuration) {
+	r.config = &c
+}
+
+//SetContext sets the context of the runs, cancelling it stops them
+func (r *requester) SetContext(ctx context.Context) {
+	r.ctx = ctx
+}
+
+//SetStartAt delays the workers of the first run until the given
+//time, it is used to start the workers of several clients together
+func (r *requester) SetStartAt(t time.Time) {
+	r.startAt = t
+}
+
+//SetSeed sets the seed of the random streams, it replaces the
+//seed of the config file when not 0
+func (r *requester) SetSeed(seed int64) {
+	r.seed = seed
+}
+
+//SetKeepSamples sets the number of requests kept as samples in the
+//result, the latency percentiles always cover every request
+func (r *requester) SetKeepSamples(n int) {
+	r.keepSamples = n
+}
+
+//SetReload reloads the configuration with load each time trigger
+//fires while the benchmark runs, see Reconfigurable for the keys
+//that are applied
+func (r *requester) SetReload(trigger <-chan struct{}, load func() (conf.Configuration, error)) {
+	r.reloadTrigger = trigger
+	r.reloadConfig = load
+}
+
+//SetRunOptions keeps the options the run was started with
+//so that they are recorded in the result metadata
+func (r *requester) SetRunOptions(options interface{}) {
+	r.runOptions = options
+}
+
+//SetGlobalOptions keeps the global options of the command
+//so that they are recorded in the result metadata
+func (r *requester) SetGlobalOptions(options GlobalOptions) {
+	r.globalOptions = &options
+}
+
+//SetOutputPath sets the file where the result is saved,
+//an empty path does not save the result
+func (r *requester) SetOutputPath(path string) {
+	r.outputPath = path
+}
+
+//SetRepetitions runs the benchmark the given number of times,
+//waiting pause between runs
+func (r *requester) SetRepetitions(repetitions int, pause time.Duration) {
+	r.repetitions = repetitions
+	r.pause = pause
+}
+
+//SetProfiling sets the profiles taken of the client while
+//requests are sent, relative paths are placed next to the result file
+func (r *requester) SetProfiling(opts ProfileOptions) {
+	r.profiling = opts
+}
+
+//SetStore sets the store where every result is appended
+func (r *requester) SetStore(store *Store) {
+	r.store = store
+}
+
+func (r *requester) SetPrinter(printer StatusPrinter) {
+	r.printer = printer
+}
+
+//SetLiveView sets a view that is running while requests are sent
+func (r *requester) SetLiveView(view LiveView) {
+	r.liveView = view
+	r.TrackLiveStats()
+}
+
+//TrackLiveStats makes the runs keep the stats returned by LiveStats,
+//the workers only record them when something reads them
+func (r *requester) TrackLiveStats() {
+	if r.live == nil {
+		r.live = newLiveStats()
+	}
+}
+
+//LiveStats returns the state of the current run, it is safe
+//to call from other goroutines while the run executes
+func (r *requester) LiveStats() LiveSnapshot {
+	if r.live == nil {
+		return LiveSnapshot{}
+	}
+	return r.live.snapshot()
+}
+
+//Result returns the result of the last run
+func (r *requester) Result() Result {
+	return r.result
+}
+
+//AddHandler adds a handler that is not registered or replaces
+//a registered one, other handlers are created from the registry
 func (r *requester) AddHandler(key string, handler Handler) {
 	r.handlers[key] = handler
 }
@@ -54,30 +206,231 @@ func (r *requester) Start(handlerName string) error {
 
 	r.cancelFunc = cancel
 
-	handler, ok := r.handlers[handlerName]
+	handler, err := resolveHandler(r.handlers, handlerName)
 
-	if !ok {
-		panic("Handler Does not exit")
+	if err != nil {
+		return err
 	}
 
-	//parse config file
-	c, err := conf.ParseConfigFile(r.configFilePath)
+	c, err := r.loadConfig(handlerName)
 
 	if err != nil {
 		return err
 	}
 
-	fmt.Println(c)
+	if r.seed != 0 {
+		c.Seed = r.seed
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
 
-	handler.InitHandler(c)
+	if err := conf.AsError(validateConfig(handlerName, handler, c)); err != nil {
+		return err
+	}
+
+	metadata := newRunMetadata(handlerName, r.runOptions, c)
+	metadata.Global = r.globalOptions
+	metadata.Concurrency = r.concurrentClients
+	metadata.Duration = r.duration
+	fmt.Printf("Run %v: %v with %v clients for %v (seed %v)\n", metadata.RunID, handlerName, r.concurrentClients, r.duration, c.Seed)
+
+	thinkTime, err := newThinkTimer(c.Pacing.ThinkTime)
+	if err != nil {
+		return err
+	}
+
+	r.result = Result{Metadata: metadata}
+	r.monitor = &resourceMonitor{}
+	setup := runSetup{handler: handler, handlerName: handlerName, config: c, thinkTime: thinkTime,
+		reloader: newReloader(handlerName, handler, c)}
+
+	if r.reloadTrigger != nil {
+		reloadCtx, stopReload := context.WithCancel(ctx)
+		wait := setup.reloader.watch(reloadCtx, r.reloadTrigger, r.reloadConfig)
+		defer func() {
+			stopReload()
+			wait()
+		}()
+	}
+
+	if r.repetitions <= 1 {
+		setup.reloader.initHandler()
+		r.result.Stats, err = r.run(ctx, setup, 0, r.newProfiler(""))
+		if err != nil {
+			return err
+		}
+		r.printer.Print(r.result.Stats)
+	} else {
+		r.result.Stats = newStats()
+		for i := 0; i < r.repetitions && ctx.Err() == nil; i++ {
+			if i > 0 {
+				r.sleep(ctx, r.pause)
+				setup.reloader.resetHandler()
+			}
+
+			fmt.Printf("Repetition %v/%v\n", i+1, r.repetitions)
+			setup.reloader.initHandler()
+			stats, err := r.run(ctx, setup, i, r.newProfiler(fmt.Sprintf("-%v", i+1)))
+			if err != nil {
+				return err
+			}
+			r.printer.Print(stats)
+
+			r.result.Stats.add(stats)
+			r.result.Stats.ClientsResponses = stats.ClientsResponses
+			r.result.Repetitions = append(r.result.Repetitions, newRepetition(stats))
+		}
+
+		r.result.Stats.limitSamples(r.keepSamples, NewStream(c.Seed, sampleStream))
+		r.result.Summary = summarizeRepetitions(r.result.Repetitions)
+		PrintRepeatSummary(*r.result.Summary)
+	}
+
+	r.result.Reloads = setup.reloader.applied()
+	r.result.Resources = r.monitor.usage()
+	PrintResourceUsage(*r.result.Resources)
+	if r.store != nil {
+		if err := r.store.Append(r.result); err != nil {
+			return err
+		}
+	}
+	if r.outputPath != "" {
+		return SaveResult(r.outputPath, r.result)
+	}
+	return nil
+}
+
+func (r *requester) loadConfig(handlerName string) (conf.Configuration, error) {
+	if r.config != nil {
+		return *r.config, nil
+	}
+	if len(r.configData) > 0 {
+		//the handlers that are not registered may read any key
+		var keys []string
+		if info, err := LookupHandler(handlerName); err == nil {
+			keys = info.ConfigKeys
+		}
+		return conf.LoadSubmittedProfile(r.configData, r.profile, r.overrides, keys)
+	}
+	return conf.LoadProfileFile(r.configFilePath, r.profile, r.overrides)
+}
+
+//waitForStart sleeps until the start time, if one is set
+func (r *requester) waitForStart(ctx context.Context) error {
+	if r.startAt.IsZero() {
+		return nil
+	}
+	startAt := r.startAt
+	r.startAt = time.Time{}
+
+	wait := time.Until(startAt)
+	if wait < 0 {
+		return fmt.Errorf("start time %v already passed %v ago", startAt.Format(time.RFC3339Nano), -wait)
+	}
+	fmt.Printf("Starting at %v (in %v)\n", startAt.Format(time.RFC3339Nano), wait.Round(time.Millisecond))
+	r.sleep(ctx, wait)
+	return ctx.Err()
+}
+
+//newProfiler returns nil when no profile is requested
+func (r *requester) newProfiler(suffix string) *profiler {
+	if !r.profiling.enabled() {
+		return nil
+	}
+	dir := ""
+	if r.outputPath != "" {
+		dir = filepath.Dir(r.outputPath)
+	}
+	return newProfiler(r.profiling, dir, suffix)
+}
+
+//run starts the workers and waits for their stats, the
+//profiler, if any, covers only the time requests are sent.
+//The random streams of the workers depend on the seed and on
+//the repetition so that every repetition makes other choices.
+func (r *requester) run(ctx context.Context, setup runSetup, repetition int, prof *profiler) (Stats, error) {
+	handler, c := setup.handler, setup.config
+	policy := c.Retry[setup.handlerName]
+
+	if err := r.waitForStart(ctx); err != nil {
+		return Stats{}, err
+	}
+
+	if r.live != nil {
+		r.live.reset(setup.handlerName, r.duration)
+		defer r.live.finish()
+	}
+
+	if prof != nil {
+		if err := prof.start(); err != nil {
+			return Stats{}, err
+		}
+	}
+
+	r.monitor.start()
+	defer r.monitor.stopMonitor()
+
+	if r.liveView != nil {
+		viewCtx, stopView := context.WithCancel(ctx)
+		viewDone := make(chan struct{})
+		go func() {
+			r.liveView.Run(viewCtx)
+			close(viewDone)
+		}()
+		defer func() {
+			stopView()
+			<-viewDone
+		}()
+	}
 
 	responseChan := make(chan Stats)
+	perWorker := 0
+	if r.concurrentClients > 0 {
+		perWorker = (r.keepSamples + r.concurrentClients - 1) / r.concurrentClients
+	}
 	for i := 0; i < r.concurrentClients; i++ {
-		go r.worker(handler, ctx, responseChan)
+		handlerRnd := NewStream(c.Seed, int64(repetition), int64(i), handlerStream)
+		pacingRnd := NewStream(c.Seed, int64(repetition), int64(i), pacingStream)
+		p := pacer{thinkTime: setup.thinkTime, rate: setup.reloader.rate, rnd: pacingRnd}
+		keep := reservoir{limit: perWorker, rnd: NewStream(c.Seed, int64(repetition), int64(i), sampleStream)}
+		go r.worker(handler, handlerRnd, newRetrier(policy, pacingRnd), p, keep, ctx, responseChan)
 	}
 
-	r.aggregateResponses(responseChan)
-	return nil
+	stats := r.aggregateResponses(responseChan)
+	stats.limitSamples(r.keepSamples, NewStream(c.Seed, int64(repetition), sampleStream))
+
+	if prof != nil {
+		if err := prof.stop(); err != nil {
+			return stats, err
+		}
+		r.result.Profiles = append(r.result.Profiles, prof.files...)
+	}
+
+	r.failMu.Lock()
+	defer r.failMu.Unlock()
+	return stats, r.failure
+}
+
+//fail stops the run because of err, the first error is the one
+//the run returns
+func (r *requester) fail(err error) {
+	r.failMu.Lock()
+	if r.failure == nil {
+		r.failure = err
+	}
+	r.failMu.Unlock()
+	r.cancelFunc()
+}
+
+func (r *requester) sleep(ctx context.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
 }
 
 func (r *requester) Stop() error {
@@ -88,14 +441,10 @@ func (r *requester) Stop() error {
 	return nil
 }
 
-func (r *requester) aggregateResponses(responseChan <-chan Stats) {
-	aggregatedStats := Stats{MinRequestTime: time.Hour}
+func (r *requester) aggregateResponses(responseChan <-chan Stats) Stats {
+	aggregatedStats := newStats()
 	for s := range responseChan {
-		aggregatedStats.NumErrs += s.NumErrs
-		aggregatedStats.NumRequests += s.NumRequests
-		aggregatedStats.TotDuration += s.TotDuration
-		aggregatedStats.MaxRequestTime = util.MaxDuration(aggregatedStats.MaxRequestTime, s.MaxRequestTime)
-		aggregatedStats.MinRequestTime = util.MinDuration(aggregatedStats.MinRequestTime, s.MinRequestTime)
+		aggregatedStats.add(s)
 		aggregatedStats.ClientsResponses++
 
 		if aggregatedStats.ClientsResponses >= r.concurrentClients {
@@ -103,37 +452,93 @@ func (r *requester) aggregateResponses(responseChan <-chan Stats) {
 		}
 	}
 
-	r.printer.Print(aggregatedStats)
+	return aggregatedStats
 }
 
-func (r *requester) worker(handler Handler, ctx context.Context, responseChan chan<- Stats) {
-	stats := Stats{MinRequestTime: time.Hour} //TODO improve Min
+func (r *requester) worker(handler Handler, rnd *rand.Rand, rt retrier, p pacer, keep reservoir, ctx context.Context, responseChan chan<- Stats) {
+	stats := newStats() //TODO improve Min
 
 	start := time.Now()
 
+	//a panic of the handler fails the run instead of the process
+	defer func() {
+		if p := recover(); p != nil {
+			r.fail(fmt.Errorf("handler panicked while sending a request: %v", p))
+			stats.TotElapsed = time.Since(start)
+			responseChan <- stats
+		}
+	}()
+
 	for {
 		select {
 		case <-ctx.Done():
+			stats.TotElapsed = time.Since(start)
 			responseChan <- stats
-			fmt.Println("Done")
 			return
 		default:
 			if time.Since(start) > r.duration {
+				stats.TotElapsed = time.Since(start)
 				responseChan <- stats
 				return
 			}
 
-			s := handler.DoRequest()
+			requestStart := time.Now()
+			if r.live != nil {
+				r.live.begin()
+			}
+			res := rt.do(ctx, handler, rnd)
+			s := res.Status
 			duration := s.EndTime.Sub(s.StartTime)
 			stats.TotDuration += duration
 			stats.MaxRequestTime = util.MaxDuration(stats.MaxRequestTime, duration)
 			stats.MinRequestTime = util.MinDuration(stats.MinRequestTime, duration)
 			stats.NumRequests++
-			if !s.Success {
+			sample := Sample{
+				Start:    s.StartTime.UnixNano(),
+				Latency:  duration,
+				Success:  s.Success,
+				Node:     s.Node,
+				ErrClass: ClassOf(s.Err),
+			}
+			stats.record(sample)
+			keep.keep(&stats, sample)
+			if r.live != nil {
+				r.live.record(sample, s.Err)
+			}
+			stats.NumRetries += res.Attempts - 1
+			stats.RetryDuration += res.RetryDuration
+
+			switch {
+			case s.Success && res.Attempts == 1:
+				stats.FirstAttemptSuccesses++
+			case s.Success:
+				stats.EventualSuccesses++
+			default:
 				stats.NumErrs++
+				stats.Errors[ClassOf(s.Err)]++
 			}
 
+			stats.ThinkDuration += r.think(ctx, p.pause(requestStart), start)
+
 		}
 	}
 
 }
+
+//think sleeps for the pause without going past the end of the
+//run and returns the time actually spent sleeping
+func (r *requester) think(ctx context.Context, pause time.Duration, start time.Time) time.Duration {
+	if remaining := r.duration - time.Since(start); pause > remaining {
+		pause = remaining
+	}
+	if pause <= 0 {
+		return 0
+	}
+
+	thinkStart := time.Now()
+	select {
+	case <-ctx.Done():
+	case <-time.After(pause):
+	}
+	return time.Since(thinkStart)
+}
@@ -0,0 +1,206 @@
+package Client
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const (
+	resourceInterval = 500 * time.Millisecond
+	//cpuSaturation is the share of the usable cores above which
+	//the client is considered the bottleneck
+	cpuSaturation = 0.9
+)
+
+//ResourceSample is the state of the client process at one instant
+type ResourceSample struct {
+	Time time.Time `json:"time"`
+	//CPU is the share of the usable cores used since the previous sample
+	CPU        float64 `json:"cpu"`
+	Goroutines int     `json:"goroutines"`
+	HeapAlloc  uint64  `json:"heapAlloc"`
+	Sys        uint64  `json:"sys"`
+	NumGC      uint32  `json:"numGC"`
+	//GCPause is the GC pause time since the previous sample
+	GCPause time.Duration `json:"gcPause"`
+	//MaxGCPause is the longest single GC pause since the previous sample
+	MaxGCPause time.Duration `json:"maxGCPause"`
+}
+
+//ResourceUsage summarizes what the client used during the measurement
+type ResourceUsage struct {
+	Cores          int              `json:"cores"`
+	CPUAvailable   bool             `json:"cpuAvailable"`
+	AvgCPU         float64          `json:"avgCPU"`
+	MaxCPU         float64          `json:"maxCPU"`
+	MaxGoroutines  int              `json:"maxGoroutines"`
+	MaxHeapAlloc   uint64           `json:"maxHeapAlloc"`
+	MaxSys         uint64           `json:"maxSys"`
+	NumGC          uint32           `json:"numGC"`
+	TotalGCPause   time.Duration    `json:"totalGCPause"`
+	MaxGCPause     time.Duration    `json:"maxGCPause"`
+	Samples        []ResourceSample `json:"samples,omitempty"`
+	SaturationHint string           `json:"saturationHint,omitempty"`
+}
+
+//resourceMonitor samples the client process while runs execute,
+//it can be started and stopped several times to cover only the
+//measurement windows of repeated runs
+type resourceMonitor struct {
+	mu      sync.Mutex
+	stop    chan struct{}
+	done    chan struct{}
+	samples []ResourceSample
+
+	lastWall  time.Time
+	lastCPU   time.Duration
+	lastPause uint64
+	lastNumGC uint32
+	cpuOK     bool
+	//baseGC is the GC count when the monitor was first started
+	baseGC  uint32
+	started bool
+}
+
+func (m *resourceMonitor) start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	m.lastWall = time.Now()
+	m.lastCPU, m.cpuOK = processCPUTime()
+	m.lastPause, m.lastNumGC = mem.PauseTotalNs, mem.NumGC
+	if !m.started {
+		m.baseGC = mem.NumGC
+		m.started = true
+	}
+
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+	go m.loop(m.stop, m.done)
+}
+
+func (m *resourceMonitor) loop(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(resourceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			m.sample()
+			return
+		case <-ticker.C:
+			m.sample()
+		}
+	}
+}
+
+func (m *resourceMonitor) stopMonitor() {
+	m.mu.Lock()
+	stop, done := m.stop, m.done
+	m.stop, m.done = nil, nil
+	m.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+func (m *resourceMonitor) sample() {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	now := time.Now()
+	cpu, ok := processCPUTime()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := ResourceSample{
+		Time:       now,
+		Goroutines: runtime.NumGoroutine(),
+		HeapAlloc:  mem.HeapAlloc,
+		Sys:        mem.Sys,
+		NumGC:      mem.NumGC,
+		GCPause:    time.Duration(mem.PauseTotalNs - m.lastPause),
+		MaxGCPause: longestPause(&mem, m.lastNumGC),
+	}
+	if wall := now.Sub(m.lastWall); ok && m.cpuOK && wall > 0 {
+		s.CPU = float64(cpu-m.lastCPU) / float64(wall) / float64(usableCores())
+	}
+
+	m.lastWall, m.lastCPU, m.lastPause, m.lastNumGC = now, cpu, mem.PauseTotalNs, mem.NumGC
+	m.cpuOK = m.cpuOK && ok
+	m.samples = append(m.samples, s)
+}
+
+//usage summarizes the samples taken so far
+func (m *resourceMonitor) usage() *ResourceUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u := ResourceUsage{Cores: usableCores(), CPUAvailable: m.cpuOK, Samples: m.samples}
+	if len(m.samples) == 0 {
+		return &u
+	}
+
+	for _, s := range m.samples {
+		u.AvgCPU += s.CPU
+		if s.CPU > u.MaxCPU {
+			u.MaxCPU = s.CPU
+		}
+		if s.Goroutines > u.MaxGoroutines {
+			u.MaxGoroutines = s.Goroutines
+		}
+		if s.HeapAlloc > u.MaxHeapAlloc {
+			u.MaxHeapAlloc = s.HeapAlloc
+		}
+		if s.Sys > u.MaxSys {
+			u.MaxSys = s.Sys
+		}
+		if s.MaxGCPause > u.MaxGCPause {
+			u.MaxGCPause = s.MaxGCPause
+		}
+		u.TotalGCPause += s.GCPause
+	}
+	u.AvgCPU /= float64(len(m.samples))
+	u.NumGC = m.samples[len(m.samples)-1].NumGC - m.baseGC
+
+	if u.CPUAvailable && u.AvgCPU >= cpuSaturation {
+		u.SaturationHint = fmt.Sprintf("client CPU averaged %.0f%% of %v usable cores, "+
+			"throughput was likely limited by the client and not by the system under test",
+			u.AvgCPU*100, u.Cores)
+	}
+	return &u
+}
+
+//longestPause returns the longest pause of the GC cycles that ended
+//after the first numGC ones, the runtime keeps only the last 256 pauses
+func longestPause(mem *runtime.MemStats, numGC uint32) time.Duration {
+	cycles := mem.NumGC - numGC
+	if cycles > uint32(len(mem.PauseNs)) {
+		cycles = uint32(len(mem.PauseNs))
+	}
+	var longest uint64
+	for i := uint32(0); i < cycles; i++ {
+		pause := mem.PauseNs[(mem.NumGC-1-i)%uint32(len(mem.PauseNs))]
+		if pause > longest {
+			longest = pause
+		}
+	}
+	return time.Duration(longest)
+}
+
+//usableCores is the number of cores the Go runtime can use
+func usableCores() int {
+	cores := runtime.GOMAXPROCS(0)
+	if n := runtime.NumCPU(); n < cores {
+		cores = n
+	}
+	return cores
+}
@@ -0,0 +1,102 @@
+package Client
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/jffp113/Thesis_Client/conf"
+)
+
+//Version of the client, set at build time with
+//-ldflags "-X github.com/jffp113/Thesis_Client/Client.Version=..."
+var Version = "dev"
+
+//RunMetadata describes what produced a result
+type RunMetadata struct {
+	RunID         string             `json:"runId"`
+	Handler       string             `json:"handler"`
+	Concurrency   int                `json:"concurrency"`
+	Duration      time.Duration      `json:"duration"`
+	Options       interface{}        `json:"options,omitempty"`
+	Global        *GlobalOptions     `json:"global,omitempty"`
+	Config        conf.Configuration `json:"config"`
+	GoVersion     string             `json:"goVersion"`
+	NumCPU        int                `json:"numCPU"`
+	StartTime     time.Time          `json:"startTime"`
+	ClientVersion string             `json:"clientVersion"`
+}
+
+//GlobalOptions are the options given before the command
+type GlobalOptions struct {
+	Config  string `json:"config,omitempty"`
+	Profile string `json:"profile,omitempty"`
+	Store   string `json:"store,omitempty"`
+	//Set is the --set list as given, with the values of secret keys redacted
+	Set []string `json:"set,omitempty"`
+}
+
+//Result is what is saved after a run
+type Result struct {
+	Metadata RunMetadata `json:"metadata"`
+	//Stats of the run, aggregated over all repetitions
+	Stats       Stats          `json:"stats"`
+	Repetitions []Repetition   `json:"repetitions,omitempty"`
+	Summary     *RepeatSummary `json:"summary,omitempty"`
+	//Resources used by the client during the measurement
+	Resources *ResourceUsage `json:"resources,omitempty"`
+	//Reloads are the config changes applied while the run executed
+	Reloads []Reload `json:"reloads,omitempty"`
+	//Profiles lists the profile files written during the run
+	Profiles []string `json:"profiles,omitempty"`
+	//Sources are the results a merged result was built from
+	Sources []Source `json:"sources,omitempty"`
+}
+
+func newRunMetadata(handlerName string, options interface{}, c conf.Configuration) RunMetadata {
+	return RunMetadata{
+		RunID:         newRunID(),
+		Handler:       handlerName,
+		Options:       options,
+		Config:        c.Redacted(),
+		GoVersion:     runtime.Version(),
+		NumCPU:        runtime.NumCPU(),
+		StartTime:     time.Now(),
+		ClientVersion: Version,
+	}
+}
+
+func newRunID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+//SaveResult writes the result as indented JSON
+func SaveResult(path string, result Result) error {
+	buf, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf, 0644)
+}
+
+//LoadResult reads a result written by SaveResult
+func LoadResult(path string) (Result, error) {
+	var result Result
+	f, err := os.Open(path)
+	if err != nil {
+		return result, err
+	}
+	defer f.Close()
+
+	err = json.NewDecoder(f).Decode(&result)
+	result.Stats.fillSummaries()
+	return result, err
+}
@@ -0,0 +1,77 @@
+package Client
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/jffp113/Thesis_Client/conf"
+)
+
+//attemptResult is the outcome of a request after all its attempts
+type attemptResult struct {
+	//Status of the last attempt
+	Status   RequestStatus
+	Attempts int
+	//RetryDuration is the time spent in failed attempts and backoff
+	RetryDuration time.Duration
+}
+
+type retrier struct {
+	policy conf.RetryPolicy
+	rnd    *rand.Rand
+}
+
+func newRetrier(policy conf.RetryPolicy, rnd *rand.Rand) retrier {
+	return retrier{policy: policy, rnd: rnd}
+}
+
+//do executes the request until it succeeds, fails with an error
+//class that is not retried or runs out of attempts, handlerRnd is
+//passed to every attempt
+func (rt retrier) do(ctx context.Context, handler Handler, handlerRnd *rand.Rand) attemptResult {
+	var result attemptResult
+	maxAttempts := rt.policy.Attempts()
+
+	for {
+		s := handler.DoRequest(handlerRnd)
+		result.Status = s
+		result.Attempts++
+
+		if s.Success || result.Attempts >= maxAttempts {
+			return result
+		}
+
+		if !rt.policy.ShouldRetry(string(ClassOf(s.Err))) {
+			return result
+		}
+
+		retryStart := time.Now()
+		result.RetryDuration += s.EndTime.Sub(s.StartTime)
+
+		select {
+		case <-ctx.Done():
+			return result
+		case <-time.After(rt.backoff(result.Attempts)):
+		}
+
+		result.RetryDuration += time.Since(retryStart)
+	}
+}
+
+//backoff applies jitter to the policy backoff
+func (rt retrier) backoff(retry int) time.Duration {
+	backoff := rt.policy.Backoff(retry)
+	jitter := rt.policy.Jitter
+
+	if jitter <= 0 || backoff <= 0 {
+		return backoff
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+
+	//Pick uniformly in [backoff*(1-jitter), backoff]
+	spread := float64(backoff) * jitter
+	return backoff - time.Duration(rt.rnd.Float64()*spread)
+}
@@ -0,0 +1,37 @@
+package Client
+
+import (
+	"math/rand"
+)
+
+//Ids of the streams of each worker
+const (
+	//handlerStream feeds the random choices of the handler
+	handlerStream = iota
+	//pacingStream feeds think times and retry jitter, it is kept
+	//apart so that changing the pacing does not change the
+	//choices of the handler
+	pacingStream
+	//sampleStream picks the requests kept as samples
+	sampleStream
+)
+
+//NewStream returns a random stream derived from the seed and the
+//stream ids, the same seed and ids always give the same sequence.
+//Handlers use NewStream(config.Seed) for the choices of InitHandler.
+func NewStream(seed int64, ids ...int64) *rand.Rand {
+	x := splitmix(uint64(seed))
+	for _, id := range ids {
+		x = splitmix(x ^ splitmix(uint64(id)))
+	}
+	return rand.New(rand.NewSource(int64(x)))
+}
+
+//splitmix is the finalizer of the SplitMix64 generator, it spreads
+//nearby inputs such as consecutive worker ids over unrelated outputs
+func splitmix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
@@ -0,0 +1,442 @@
+package Client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jffp113/Thesis_Client/conf"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	//maxQueuedJobs is the number of jobs that can wait for their turn
+	maxQueuedJobs = 64
+	//liveStreamInterval is the time between the snapshots of a live stream
+	liveStreamInterval = time.Second
+)
+
+//JobRequest is a benchmark submitted to the server
+type JobRequest struct {
+	Handler     string `json:"handler"`
+	Concurrency int    `json:"concurrency"`
+	//Duration of each run, such as "30s"
+	Duration    string `json:"duration"`
+	Repetitions int    `json:"repetitions,omitempty"`
+	Pause       string `json:"pause,omitempty"`
+	//Config is YAML applied over the config file of the server
+	Config string `json:"config,omitempty"`
+	//Profile of the config file, the profile of the server when empty
+	Profile string `json:"profile,omitempty"`
+	Seed    int64  `json:"seed,omitempty"`
+	//KeepSamples is the number of requests kept as samples,
+	//DefaultKeepSamples when 0
+	KeepSamples int `json:"keepSamples,omitempty"`
+}
+
+type JobState string
+
+const (
+	JobQueued    JobState = "queued"
+	JobRunning   JobState = "running"
+	JobDone      JobState = "done"
+	JobFailed    JobState = "failed"
+	JobCancelled JobState = "cancelled"
+)
+
+//JobStatus is what the server reports about a job
+type JobStatus struct {
+	ID        string     `json:"id"`
+	Request   JobRequest `json:"request"`
+	State     JobState   `json:"state"`
+	Error     string     `json:"error,omitempty"`
+	Submitted time.Time  `json:"submitted"`
+	Started   *time.Time `json:"started,omitempty"`
+	Finished  *time.Time `json:"finished,omitempty"`
+	//RunID of the result once the job finished
+	RunID string        `json:"runId,omitempty"`
+	Live  *LiveSnapshot `json:"live,omitempty"`
+}
+
+type job struct {
+	status    JobStatus
+	config    conf.Configuration
+	duration  time.Duration
+	pause     time.Duration
+	ctx       context.Context
+	cancel    context.CancelFunc
+	requester *requester
+	result    *Result
+}
+
+//Server runs the jobs submitted over its HTTP API one at a time:
+//
+//	POST /jobs                submit a JobRequest
+//	GET  /jobs                list the jobs
+//	GET  /jobs/{id}           status of a job
+//	GET  /jobs/{id}/live      status with live stats every second, one JSON object per line
+//	POST /jobs/{id}/cancel    cancel a queued or running job
+//	GET  /jobs/{id}/result    result of a finished job
+type Server struct {
+	mu         sync.Mutex
+	configPath string
+	profile    string
+	overrides  []conf.Override
+	global     *GlobalOptions
+	handlers   map[string]Handler
+	used       map[string]bool
+	store      *Store
+	printer    StatusPrinter
+	jobs       map[string]*job
+	queue      chan *job
+}
+
+//NewServer creates a server whose jobs are based on the config file
+func NewServer(configPath string) *Server {
+	return &Server{
+		configPath: configPath,
+		handlers:   make(map[string]Handler),
+		used:       make(map[string]bool),
+		printer:    DefaultPrinter{},
+		jobs:       make(map[string]*job),
+		queue:      make(chan *job, maxQueuedJobs),
+	}
+}
+
+//AddHandler adds a handler that is not registered or replaces
+//a registered one, other handlers are created from the registry
+func (s *Server) AddHandler(key string, handler Handler) {
+	s.handlers[key] = handler
+}
+
+//SetProfile sets the profile of the config file used by
+//the jobs that do not choose one
+func (s *Server) SetProfile(profile string) {
+	s.profile = profile
+}
+
+//SetOverrides sets the overrides of config keys applied to every
+//job before the config of the job
+func (s *Server) SetOverrides(overrides []conf.Override) {
+	s.overrides = overrides
+}
+
+//SetGlobalOptions keeps the global options the server was started
+//with so that they are recorded in the metadata of every job
+func (s *Server) SetGlobalOptions(options GlobalOptions) {
+	s.global = &options
+}
+
+//SetStore sets the store where the result of every job is appended
+func (s *Server) SetStore(store *Store) {
+	s.store = store
+}
+
+//ListenAndServe runs the jobs and serves the API until it fails
+func (s *Server) ListenAndServe(addr string) error {
+	go s.process()
+	return http.ListenAndServe(addr, s)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if parts[0] != "jobs" || len(parts) > 3 {
+		http.NotFound(w, req)
+		return
+	}
+
+	if len(parts) == 1 {
+		switch req.Method {
+		case http.MethodGet:
+			s.list(w)
+		case http.MethodPost:
+			s.submit(w, req)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	j, ok := s.job(parts[1])
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown job %v", parts[1]), http.StatusNotFound)
+		return
+	}
+
+	action := ""
+	if len(parts) == 3 {
+		action = parts[2]
+	}
+	switch {
+	case action == "" && req.Method == http.MethodGet:
+		writeJSON(w, s.status(j))
+	case action == "live" && req.Method == http.MethodGet:
+		s.streamLive(w, req, j)
+	case action == "cancel" && req.Method == http.MethodPost:
+		s.cancelJob(j)
+		writeJSON(w, s.status(j))
+	case action == "result" && req.Method == http.MethodGet:
+		s.writeResult(w, j)
+	case action == "" || action == "live" || action == "cancel" || action == "result":
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func (s *Server) submit(w http.ResponseWriter, req *http.Request) {
+	var jr JobRequest
+	if err := json.NewDecoder(req.Body).Decode(&jr); err != nil {
+		http.Error(w, fmt.Sprintf("invalid job: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	j, err := s.newJob(jr)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	select {
+	case s.queue <- j:
+		s.jobs[j.status.ID] = j
+	default:
+		s.mu.Unlock()
+		http.Error(w, "too many queued jobs", http.StatusServiceUnavailable)
+		return
+	}
+	s.mu.Unlock()
+
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, s.status(j))
+}
+
+//newJob checks the request so that mistakes are reported on
+//submission and not when the job gets its turn
+func (s *Server) newJob(jr JobRequest) (*job, error) {
+	s.mu.Lock()
+	handler, err := resolveHandler(s.handlers, jr.Handler)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if jr.Concurrency < 1 {
+		return nil, fmt.Errorf("concurrency must be at least 1")
+	}
+
+	duration, err := time.ParseDuration(jr.Duration)
+	if err != nil || duration <= 0 {
+		return nil, fmt.Errorf("invalid duration %q", jr.Duration)
+	}
+	var pause time.Duration
+	if jr.Pause != "" {
+		if pause, err = time.ParseDuration(jr.Pause); err != nil {
+			return nil, fmt.Errorf("invalid pause %q", jr.Pause)
+		}
+	}
+
+	base, err := ioutil.ReadFile(s.configPath)
+	if err != nil {
+		return nil, err
+	}
+	profile := jr.Profile
+	if profile == "" {
+		profile = s.profile
+	}
+	config, err := conf.ParseSubmittedProfile(base, profile, s.overrides, []byte(jr.Config))
+	if err != nil {
+		return nil, err
+	}
+	if err := conf.AsError(validateConfig(jr.Handler, handler, config)); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &job{
+		status:   JobStatus{ID: newRunID(), Request: jr, State: JobQueued, Submitted: time.Now()},
+		config:   config,
+		duration: duration,
+		pause:    pause,
+		ctx:      ctx,
+		cancel:   cancel,
+	}, nil
+}
+
+//process runs the queued jobs in submission order
+func (s *Server) process() {
+	for j := range s.queue {
+		s.runJob(j)
+	}
+}
+
+func (s *Server) runJob(j *job) {
+	s.mu.Lock()
+	handler := s.handlers[j.status.Request.Handler]
+	s.mu.Unlock()
+
+	r := NewRequester()
+	r.SetContext(j.ctx)
+	r.SetConfig(j.config)
+	r.SetConcurrentClients(j.status.Request.Concurrency)
+	r.SetDuration(j.duration)
+	r.SetRepetitions(j.status.Request.Repetitions, j.pause)
+	r.SetSeed(j.status.Request.Seed)
+	if j.status.Request.KeepSamples > 0 {
+		r.SetKeepSamples(j.status.Request.KeepSamples)
+	}
+	r.SetRunOptions(j.status.Request)
+	if s.global != nil {
+		r.SetGlobalOptions(*s.global)
+	}
+	r.SetPrinter(s.printer)
+	r.SetStore(s.store)
+	r.TrackLiveStats()
+	r.AddHandler(j.status.Request.Handler, handler)
+
+	s.mu.Lock()
+	if j.status.State != JobQueued {
+		//cancelled while waiting
+		s.mu.Unlock()
+		return
+	}
+	started := time.Now()
+	j.status.State = JobRunning
+	j.status.Started = &started
+	j.requester = &r
+	s.mu.Unlock()
+
+	//handlers keep state such as pending transactions between runs
+	if s.used[j.status.Request.Handler] {
+		if resetter, ok := handler.(Resetter); ok {
+			resetter.Reset()
+		}
+	}
+	s.used[j.status.Request.Handler] = true
+
+	err := startRecovered(&r, j.status.Request.Handler)
+	result := r.Result()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	finished := time.Now()
+	j.status.Finished = &finished
+	switch {
+	case err != nil:
+		j.status.State = JobFailed
+		j.status.Error = err.Error()
+	case j.ctx.Err() != nil:
+		j.status.State = JobCancelled
+	default:
+		j.status.State = JobDone
+	}
+	if err == nil {
+		j.result = &result
+		j.status.RunID = result.Metadata.RunID
+	}
+	j.cancel()
+}
+
+//startRecovered starts the requester and turns a panic of the handler,
+//such as an InitHandler that cannot reach its nodes, into an error so
+//that it fails the job and not the server
+func startRecovered(r *requester, handlerName string) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("handler %v panicked: %v", handlerName, p)
+		}
+	}()
+	return r.Start(handlerName)
+}
+
+func (s *Server) job(id string) (*job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	return j, ok
+}
+
+func (s *Server) status(j *job) JobStatus {
+	s.mu.Lock()
+	status := j.status
+	r := j.requester
+	s.mu.Unlock()
+
+	if status.State == JobRunning && r != nil {
+		live := r.LiveStats()
+		status.Live = &live
+	}
+	return status
+}
+
+func (s *Server) list(w http.ResponseWriter) {
+	s.mu.Lock()
+	jobs := make([]*job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	s.mu.Unlock()
+
+	statuses := make([]JobStatus, len(jobs))
+	for i, j := range jobs {
+		statuses[i] = s.status(j)
+		statuses[i].Live = nil
+	}
+	sort.Slice(statuses, func(i, k int) bool { return statuses[i].Submitted.Before(statuses[k].Submitted) })
+	writeJSON(w, statuses)
+}
+
+func (s *Server) cancelJob(j *job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if j.status.State == JobQueued {
+		finished := time.Now()
+		j.status.State = JobCancelled
+		j.status.Finished = &finished
+	}
+	j.cancel()
+}
+
+//streamLive writes the status every second until the job ends
+func (s *Server) streamLive(w http.ResponseWriter, req *http.Request, j *job) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+
+	ticker := time.NewTicker(liveStreamInterval)
+	defer ticker.Stop()
+	for {
+		status := s.status(j)
+		if err := enc.Encode(status); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if status.State != JobQueued && status.State != JobRunning {
+			return
+		}
+
+		select {
+		case <-req.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) writeResult(w http.ResponseWriter, j *job) {
+	s.mu.Lock()
+	result, state := j.result, j.status.State
+	s.mu.Unlock()
+
+	if result == nil {
+		http.Error(w, fmt.Sprintf("job is %v and has no result", state), http.StatusConflict)
+		return
+	}
+	writeJSON(w, result)
+}
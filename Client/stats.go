@@ -0,0 +1,367 @@
+package Client
+
+import (
+	"github.com/jffp113/Thesis_Client/Client/util"
+	"math/rand"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//DefaultKeepSamples is the number of requests a run keeps as samples
+const DefaultKeepSamples = 100000
+
+//Sample is a single request as seen by a worker
+type Sample struct {
+	//Start of the request in unix nanoseconds
+	Start   int64         `json:"t"`
+	Latency time.Duration `json:"l"`
+	Success bool          `json:"ok"`
+	Node    string        `json:"n,omitempty"`
+	//Class of the error if the request failed
+	ErrClass ErrorClass `json:"e,omitempty"`
+}
+
+func (s Sample) End() int64 {
+	return s.Start + int64(s.Latency)
+}
+
+type Stats struct {
+	TotDuration      time.Duration
+	MinRequestTime   time.Duration
+	MaxRequestTime   time.Duration
+	NumRequests      int
+	NumErrs          int
+	ClientsResponses int
+
+	//Retry accounting, TotDuration only includes the last attempt
+	//of each request while the time lost in failed attempts and
+	//backoff is kept in RetryDuration
+	FirstAttemptSuccesses int
+	EventualSuccesses     int
+	NumRetries            int
+	RetryDuration         time.Duration
+
+	//Errors counts failed requests by error class
+	Errors map[ErrorClass]int
+
+	//ThinkDuration is the time workers paused between requests,
+	//it is not part of any request latency
+	ThinkDuration time.Duration
+	//TotElapsed is the sum of the wall time of every worker
+	TotElapsed time.Duration
+
+	//Latency is the distribution of the latencies of the successful
+	//requests, percentiles are computed from it
+	Latency LatencyHistogram
+	//Nodes breaks down the requests by the node that served them
+	Nodes map[string]*NodeTotals `json:",omitempty"`
+	//Timeline counts the requests completed in every second
+	Timeline Timeline
+
+	//Samples keeps a uniform random subset of the requests, bounded
+	//by the samples the run keeps, so that results can be compared
+	Samples []Sample `json:",omitempty"`
+}
+
+//NodeTotals are the requests served by one node
+type NodeTotals struct {
+	Requests int
+	Errors   int
+	Latency  LatencyHistogram
+}
+
+func newStats() Stats {
+	return Stats{MinRequestTime: time.Hour, Errors: make(map[ErrorClass]int), Nodes: make(map[string]*NodeTotals)}
+}
+
+//record counts the request in the latency distribution, the
+//nodes and the timeline
+func (s *Stats) record(sample Sample) {
+	if sample.Success {
+		s.Latency.record(sample.Latency)
+	}
+	s.Timeline.record(sample.Start, sample.End())
+
+	node := sample.Node
+	if node == "" {
+		node = "-"
+	}
+	if s.Nodes == nil {
+		s.Nodes = make(map[string]*NodeTotals)
+	}
+	totals, ok := s.Nodes[node]
+	if !ok {
+		totals = &NodeTotals{}
+		s.Nodes[node] = totals
+	}
+	totals.Requests++
+	if sample.Success {
+		totals.Latency.record(sample.Latency)
+	} else {
+		totals.Errors++
+	}
+}
+
+//fillSummaries builds the latency distribution, the nodes and the
+//timeline from the samples of results saved before they existed,
+//those results kept every request
+func (s *Stats) fillSummaries() {
+	if s.Latency.Count > 0 || s.Timeline.Last > 0 || len(s.Samples) == 0 {
+		return
+	}
+	for _, sample := range s.Samples {
+		s.record(sample)
+	}
+}
+
+//reservoir keeps a uniform random subset of at most limit of the
+//requests of a worker as samples
+type reservoir struct {
+	limit int
+	rnd   *rand.Rand
+}
+
+//keep offers the sample of the last request counted in the stats
+func (r reservoir) keep(s *Stats, sample Sample) {
+	if len(s.Samples) < r.limit {
+		s.Samples = append(s.Samples, sample)
+		return
+	}
+	if j := r.rnd.Intn(s.NumRequests); j < r.limit {
+		s.Samples[j] = sample
+	}
+}
+
+//limitSamples keeps a uniform random subset of at most limit samples
+//ordered by start
+func (s *Stats) limitSamples(limit int, rnd *rand.Rand) {
+	if len(s.Samples) <= limit {
+		return
+	}
+	for i := 0; i < limit; i++ {
+		j := i + rnd.Intn(len(s.Samples)-i)
+		s.Samples[i], s.Samples[j] = s.Samples[j], s.Samples[i]
+	}
+	s.Samples = append([]Sample(nil), s.Samples[:limit]...)
+	sort.Slice(s.Samples, func(i, j int) bool { return s.Samples[i].Start < s.Samples[j].Start })
+}
+
+//add accumulates the stats of a worker
+func (s *Stats) add(o Stats) {
+	s.NumErrs += o.NumErrs
+	s.NumRequests += o.NumRequests
+	s.TotDuration += o.TotDuration
+	s.MaxRequestTime = util.MaxDuration(s.MaxRequestTime, o.MaxRequestTime)
+	s.MinRequestTime = util.MinDuration(s.MinRequestTime, o.MinRequestTime)
+	s.FirstAttemptSuccesses += o.FirstAttemptSuccesses
+	s.EventualSuccesses += o.EventualSuccesses
+	s.NumRetries += o.NumRetries
+	s.RetryDuration += o.RetryDuration
+	s.ThinkDuration += o.ThinkDuration
+	s.TotElapsed += o.TotElapsed
+	for class, n := range o.Errors {
+		s.Errors[class] += n
+	}
+	s.Latency.add(o.Latency)
+	if len(o.Nodes) > 0 && s.Nodes == nil {
+		s.Nodes = make(map[string]*NodeTotals)
+	}
+	for node, other := range o.Nodes {
+		totals, ok := s.Nodes[node]
+		if !ok {
+			totals = &NodeTotals{}
+			s.Nodes[node] = totals
+		}
+		totals.Requests += other.Requests
+		totals.Errors += other.Errors
+		totals.Latency.add(other.Latency)
+	}
+	s.Timeline.add(o.Timeline)
+	s.Samples = append(s.Samples, o.Samples...)
+}
+
+//AvgDuration is the average time each client spent in requests
+func (s Stats) AvgDuration() time.Duration {
+	if s.ClientsResponses == 0 {
+		return 0
+	}
+	return s.TotDuration / time.Duration(s.ClientsResponses)
+}
+
+//Throughput in requests per second
+func (s Stats) Throughput() float64 {
+	avgDur := s.AvgDuration()
+	if avgDur == 0 {
+		return 0
+	}
+	return float64(s.NumRequests) / avgDur.Seconds()
+}
+
+//OfferedLoad in requests per second over the wall time of the run,
+//including the think time of the clients
+func (s Stats) OfferedLoad() float64 {
+	if s.TotElapsed == 0 || s.ClientsResponses == 0 {
+		return 0
+	}
+	avgElapsed := s.TotElapsed / time.Duration(s.ClientsResponses)
+	return float64(s.NumRequests) / avgElapsed.Seconds()
+}
+
+func (s Stats) AvgLatency() time.Duration {
+	if s.NumRequests == 0 {
+		return 0
+	}
+	return s.TotDuration / time.Duration(s.NumRequests)
+}
+
+func (s Stats) AvgThinkTime() time.Duration {
+	if s.NumRequests == 0 {
+		return 0
+	}
+	return s.ThinkDuration / time.Duration(s.NumRequests)
+}
+
+//SampledLatencies returns the sorted latencies of the successful
+//requests kept as samples
+func (s Stats) SampledLatencies() []time.Duration {
+	var latencies []time.Duration
+	for _, sample := range s.Samples {
+		if sample.Success {
+			latencies = append(latencies, sample.Latency)
+		}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	return latencies
+}
+
+//Percentiles returns the latency percentiles (0 to 100) of the
+//successful requests
+func (s Stats) Percentiles(ps ...float64) []time.Duration {
+	result := make([]time.Duration, len(ps))
+	for i, p := range ps {
+		result[i] = s.Latency.Percentile(p)
+	}
+	return result
+}
+
+//PercentileOf returns the p percentile (0 to 100) of sorted
+//latencies using the nearest rank method
+func PercentileOf(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[util.NearestRank(len(sorted), p)]
+}
+
+//ThroughputSeries returns the number of requests completed in each
+//second of the run
+func (s Stats) ThroughputSeries() []float64 {
+	series := make([]float64, len(s.Timeline.Counts))
+	for i, n := range s.Timeline.Counts {
+		series[i] = float64(n)
+	}
+	return series
+}
+
+//Window returns the start of the first request and the end of the
+//last one, both are zero when there are no requests
+func (s Stats) Window() (time.Time, time.Time) {
+	if len(s.Timeline.Counts) == 0 {
+		return time.Time{}, time.Time{}
+	}
+	return time.Unix(0, s.Timeline.First), time.Unix(0, s.Timeline.Last)
+}
+
+//Timeline counts the requests that ended in each second
+type Timeline struct {
+	//Start is the unix second of the first count
+	Start  int64 `json:"start"`
+	Counts []int `json:"counts,omitempty"`
+	//First is the start of the first request and Last the end of
+	//the last one, in unix nanoseconds
+	First int64 `json:"first"`
+	Last  int64 `json:"last"`
+}
+
+func (t *Timeline) record(start, end int64) {
+	if len(t.Counts) == 0 || start < t.First {
+		t.First = start
+	}
+	if len(t.Counts) == 0 || end > t.Last {
+		t.Last = end
+	}
+	t.count(end/int64(time.Second), 1)
+}
+
+func (t *Timeline) add(o Timeline) {
+	if len(o.Counts) == 0 {
+		return
+	}
+	if len(t.Counts) == 0 || o.First < t.First {
+		t.First = o.First
+	}
+	if len(t.Counts) == 0 || o.Last > t.Last {
+		t.Last = o.Last
+	}
+	for i, n := range o.Counts {
+		t.count(o.Start+int64(i), n)
+	}
+}
+
+//count adds n to the second, growing the counts to hold it
+func (t *Timeline) count(second int64, n int) {
+	switch {
+	case len(t.Counts) == 0:
+		t.Start = second
+		t.Counts = []int{0}
+	case second < t.Start:
+		t.Counts = append(make([]int, t.Start-second), t.Counts...)
+		t.Start = second
+	}
+	for second-t.Start >= int64(len(t.Counts)) {
+		t.Counts = append(t.Counts, 0)
+	}
+	t.Counts[second-t.Start] += n
+}
+
+//shift moves the timeline back by offset, the counts by whole seconds
+func (t Timeline) shift(offset time.Duration) Timeline {
+	t.First -= int64(offset)
+	t.Last -= int64(offset)
+	t.Start -= int64(offset.Round(time.Second) / time.Second)
+	return t
+}
+
+func percentileName(p float64) string {
+	return "p" + strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+//NodeStats are the stats of the requests served by one node
+type NodeStats struct {
+	Node       string
+	Requests   int
+	Errors     int
+	AvgLatency time.Duration
+	P50        time.Duration
+	P99        time.Duration
+}
+
+//PerNode breaks down the requests by the node that served them,
+//requests without a known node are grouped under "-"
+func (s Stats) PerNode() []NodeStats {
+	var nodes []NodeStats
+	for node, totals := range s.Nodes {
+		nodes = append(nodes, NodeStats{
+			Node:       node,
+			Requests:   totals.Requests,
+			Errors:     totals.Errors,
+			AvgLatency: totals.Latency.Mean(),
+			P50:        totals.Latency.Percentile(50),
+			P99:        totals.Latency.Percentile(99),
+		})
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Node < nodes[j].Node })
+	return nodes
+}
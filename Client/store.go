@@ -0,0 +1,190 @@
+package Client
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const storeIndexFile = "index.jsonl"
+
+//Store keeps every result in a directory, one JSON file per run,
+//plus an index with a summary line per run used by History
+type Store struct {
+	dir string
+}
+
+//StoreEntry is the summary of a stored run
+type StoreEntry struct {
+	RunID       string        `json:"runId"`
+	Handler     string        `json:"handler"`
+	Scheme      string        `json:"scheme"`
+	Concurrency int           `json:"concurrency"`
+	Duration    time.Duration `json:"duration"`
+	StartTime   time.Time     `json:"startTime"`
+	NumRequests int           `json:"numRequests"`
+	NumErrs     int           `json:"numErrs"`
+	Throughput  float64       `json:"throughput"`
+	File        string        `json:"file"`
+}
+
+//StoreFilter selects runs in History, zero fields match everything
+type StoreFilter struct {
+	Handler     string
+	Scheme      string
+	Concurrency int
+	Since       time.Time
+	Until       time.Time
+}
+
+//DefaultStoreDir is the store used when no directory is given
+func DefaultStoreDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "results"
+	}
+	return filepath.Join(home, ".thesis_client", "results")
+}
+
+func OpenStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+//Append saves the result and adds it to the index
+func (s *Store) Append(result Result) error {
+	file := result.Metadata.RunID + ".json"
+	if err := SaveResult(filepath.Join(s.dir, file), result); err != nil {
+		return err
+	}
+
+	entry := StoreEntry{
+		RunID:       result.Metadata.RunID,
+		Handler:     result.Metadata.Handler,
+		Scheme:      result.Metadata.Config.Group().Scheme,
+		Concurrency: result.Metadata.Concurrency,
+		Duration:    result.Metadata.Duration,
+		StartTime:   result.Metadata.StartTime,
+		NumRequests: result.Stats.NumRequests,
+		NumErrs:     result.Stats.NumErrs,
+		Throughput:  result.Stats.Throughput(),
+		File:        file,
+	}
+
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filepath.Join(s.dir, storeIndexFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(buf, '\n'))
+	return err
+}
+
+//History returns the stored runs that match the filter, oldest first
+func (s *Store) History(filter StoreFilter) ([]StoreEntry, error) {
+	entries, err := s.entries()
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []StoreEntry
+	for _, e := range entries {
+		if filter.match(e) {
+			matched = append(matched, e)
+		}
+	}
+	return matched, nil
+}
+
+//Load returns the stored run with the given id, a unique
+//prefix of the id is also accepted
+func (s *Store) Load(runID string) (Result, error) {
+	entry, err := s.find(runID)
+	if err != nil {
+		return Result{}, err
+	}
+	return LoadResult(filepath.Join(s.dir, entry.File))
+}
+
+func (s *Store) find(runID string) (StoreEntry, error) {
+	entries, err := s.entries()
+	if err != nil {
+		return StoreEntry{}, err
+	}
+
+	var found []StoreEntry
+	for _, e := range entries {
+		if e.RunID == runID {
+			return e, nil
+		}
+		if strings.HasPrefix(e.RunID, runID) {
+			found = append(found, e)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return StoreEntry{}, fmt.Errorf("run %v not found in %v", runID, s.dir)
+	case 1:
+		return found[0], nil
+	default:
+		return StoreEntry{}, fmt.Errorf("run id %v is ambiguous, %v runs match", runID, len(found))
+	}
+}
+
+func (s *Store) entries() ([]StoreEntry, error) {
+	f, err := os.Open(filepath.Join(s.dir, storeIndexFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []StoreEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var e StoreEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return nil, fmt.Errorf("corrupted store index: %v", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+func (f StoreFilter) match(e StoreEntry) bool {
+	if f.Handler != "" && f.Handler != e.Handler {
+		return false
+	}
+	if f.Scheme != "" && f.Scheme != e.Scheme {
+		return false
+	}
+	if f.Concurrency != 0 && f.Concurrency != e.Concurrency {
+		return false
+	}
+	if !f.Since.IsZero() && e.StartTime.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.StartTime.After(f.Until) {
+		return false
+	}
+	return true
+}
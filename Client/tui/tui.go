@@ -0,0 +1,122 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"github.com/jffp113/Thesis_Client/Client"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	clearScreen  = "\033[H\033[2J"
+	progressBars = 30
+	maxErrorLen  = 40
+)
+
+//Dashboard redraws the live stats of a run on a terminal
+type Dashboard struct {
+	out      io.Writer
+	source   func() Client.LiveSnapshot
+	interval time.Duration
+}
+
+//NewDashboard creates a dashboard that polls source on every refresh
+func NewDashboard(out io.Writer, source func() Client.LiveSnapshot) *Dashboard {
+	return &Dashboard{out: out, source: source, interval: time.Second}
+}
+
+//Run redraws the dashboard until ctx is cancelled,
+//drawing a last frame before returning
+func (d *Dashboard) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.Draw(d.source())
+			return
+		case <-ticker.C:
+			d.Draw(d.source())
+		}
+	}
+}
+
+//Draw renders a single frame
+func (d *Dashboard) Draw(s Client.LiveSnapshot) {
+	var b strings.Builder
+	b.WriteString(clearScreen)
+
+	state := "running"
+	if !s.Running {
+		state = "waiting"
+	}
+	fmt.Fprintf(&b, "Thesis Client - %v (%v)\n\n", s.Handler, state)
+
+	total := s.Elapsed + s.Remaining
+	fmt.Fprintf(&b, "Elapsed   %v / %v  %v  remaining %v\n",
+		formatClock(s.Elapsed), formatClock(total), progress(s.Elapsed, total), formatClock(s.Remaining))
+	fmt.Fprintf(&b, "Requests  %-10v In-flight %v\n", s.Requests, s.InFlight)
+	fmt.Fprintf(&b, "Tx/sec    %.2f (last 5s)\n", s.Throughput)
+	fmt.Fprintf(&b, "Latency   p50 %v  p90 %v  p99 %v (last 10s)\n",
+		round(s.P50), round(s.P90), round(s.P99))
+
+	b.WriteString("\nErrors\n")
+	if len(s.Errors) == 0 {
+		b.WriteString("  none\n")
+	}
+	var classes []string
+	for class := range s.Errors {
+		classes = append(classes, string(class))
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		fmt.Fprintf(&b, "  %-12v %v\n", class, s.Errors[Client.ErrorClass(class)])
+	}
+
+	b.WriteString("\nNodes\n")
+	if len(s.Nodes) == 0 {
+		b.WriteString("  no requests yet\n")
+	}
+	for _, n := range s.Nodes {
+		status := "ok"
+		if !n.Healthy {
+			status = "FAILING"
+		}
+		fmt.Fprintf(&b, "  %-24v %-8v req %-8v err %-6v last %v", n.Node, status, n.Requests, n.Errors, round(n.LastLatency))
+		if !n.Healthy && n.LastError != "" {
+			fmt.Fprintf(&b, "  %v", truncate(n.LastError, maxErrorLen))
+		}
+		b.WriteString("\n")
+	}
+
+	io.WriteString(d.out, b.String())
+}
+
+func progress(elapsed, total time.Duration) string {
+	filled := progressBars
+	if total > 0 && elapsed < total {
+		filled = int(float64(progressBars) * float64(elapsed) / float64(total))
+	}
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", progressBars-filled) + "]"
+}
+
+func formatClock(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%02d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(10 * time.Microsecond)
+}
+
+func truncate(s string, n int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
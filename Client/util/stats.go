@@ -0,0 +1,220 @@
+package util
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+//Mean of the values, 0 if there are none
+func Mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+//MannWhitney runs a two-sided Mann-Whitney U test and returns the
+//p-value using the normal approximation with tie correction
+func MannWhitney(a, b []float64) float64 {
+	n1, n2 := float64(len(a)), float64(len(b))
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	type ranked struct {
+		value float64
+		fromA bool
+	}
+	all := make([]ranked, 0, len(a)+len(b))
+	for _, v := range a {
+		all = append(all, ranked{v, true})
+	}
+	for _, v := range b {
+		all = append(all, ranked{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].value < all[j].value })
+
+	//Average ranks of ties and collect the tie correction term
+	rankSumA, tieTerm := 0.0, 0.0
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].value == all[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].fromA {
+				rankSumA += rank
+			}
+		}
+		t := float64(j - i)
+		tieTerm += t*t*t - t
+		i = j
+	}
+
+	u := rankSumA - n1*(n1+1)/2
+	n := n1 + n2
+	mean := n1 * n2 / 2
+	variance := n1 * n2 / 12 * ((n + 1) - tieTerm/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+
+	z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance)
+	if z < 0 {
+		z = 0
+	}
+	return math.Erfc(z / math.Sqrt2)
+}
+
+//BootstrapRatio resamples both sets with replacement and returns the
+//confidence interval of statistic(b)/statistic(a) - 1 at the given level
+func BootstrapRatio(a, b []float64, statistic func([]float64) float64,
+	iterations int, level float64, rnd *rand.Rand) (low, high float64) {
+
+	if len(a) == 0 || len(b) == 0 || iterations <= 0 {
+		return 0, 0
+	}
+
+	ratios := make([]float64, 0, iterations)
+	resA := make([]float64, len(a))
+	resB := make([]float64, len(b))
+	for i := 0; i < iterations; i++ {
+		resample(a, resA, rnd)
+		resample(b, resB, rnd)
+		base := statistic(resA)
+		if base == 0 {
+			continue
+		}
+		ratios = append(ratios, statistic(resB)/base-1)
+	}
+
+	if len(ratios) == 0 {
+		return 0, 0
+	}
+	sort.Float64s(ratios)
+	alpha := (1 - level) / 2
+	return quantile(ratios, alpha), quantile(ratios, 1-alpha)
+}
+
+//BootstrapPercentileRatio is BootstrapRatio with the p percentile
+//(0 to 100) as the statistic, using the nearest rank method. Both sets
+//are sorted once, a resample is kept as the count of draws of every
+//sorted value so its percentile is found without sorting.
+func BootstrapPercentileRatio(a, b []float64, p float64,
+	iterations int, level float64, rnd *rand.Rand) (low, high float64) {
+
+	if len(a) == 0 || len(b) == 0 || iterations <= 0 {
+		return 0, 0
+	}
+
+	sortedA := append([]float64(nil), a...)
+	sortedB := append([]float64(nil), b...)
+	sort.Float64s(sortedA)
+	sort.Float64s(sortedB)
+
+	ratios := make([]float64, 0, iterations)
+	countsA := make([]int, len(a))
+	countsB := make([]int, len(b))
+	for i := 0; i < iterations; i++ {
+		base := resampledPercentile(sortedA, countsA, p, rnd)
+		if base == 0 {
+			continue
+		}
+		ratios = append(ratios, resampledPercentile(sortedB, countsB, p, rnd)/base-1)
+	}
+
+	if len(ratios) == 0 {
+		return 0, 0
+	}
+	sort.Float64s(ratios)
+	alpha := (1 - level) / 2
+	return quantile(ratios, alpha), quantile(ratios, 1-alpha)
+}
+
+//resampledPercentile draws a resample of the sorted values with
+//replacement and returns its p percentile, counts is scratch space
+func resampledPercentile(sorted []float64, counts []int, p float64, rnd *rand.Rand) float64 {
+	for i := range counts {
+		counts[i] = 0
+	}
+	for range sorted {
+		counts[rnd.Intn(len(sorted))]++
+	}
+
+	rank := NearestRank(len(sorted), p)
+	seen := 0
+	for i, n := range counts {
+		seen += n
+		if seen > rank {
+			return sorted[i]
+		}
+	}
+	return sorted[len(sorted)-1]
+}
+
+//NearestRank returns the index of the p percentile (0 to 100) of n
+//sorted values using the nearest rank method
+func NearestRank(n int, p float64) int {
+	rank := int(p/100*float64(n)+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= n {
+		rank = n - 1
+	}
+	return rank
+}
+
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := q * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	frac := pos - float64(lower)
+	return sorted[lower]*(1-frac) + sorted[upper]*frac
+}
+
+func resample(from, to []float64, rnd *rand.Rand) {
+	for i := range to {
+		to[i] = from[rnd.Intn(len(from))]
+	}
+}
+
+//tCritical95 holds the two-sided 95% critical values of the
+//Student t distribution for 1 to 30 degrees of freedom
+var tCritical95 = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+//MeanCI95 returns the mean of the values and the bounds of its
+//95% confidence interval using the Student t distribution
+func MeanCI95(values []float64) (mean, low, high float64) {
+	mean = Mean(values)
+	n := len(values)
+	if n < 2 {
+		return mean, mean, mean
+	}
+
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(n - 1)
+
+	t := 1.96
+	if n-1 <= len(tCritical95) {
+		t = tCritical95[n-2]
+	}
+	margin := t * math.Sqrt(variance/float64(n))
+	return mean, mean - margin, mean + margin
+}
@@ -1,6 +1,7 @@
 package Algorand
 
 import (
+	cryptorand "crypto/rand"
 	"errors"
 	"fmt"
 	"github.com/jffp113/Thesis_Client/Client"
@@ -11,91 +12,236 @@ import (
 	"github.com/jffp113/go-algorand-sdk/future"
 	"github.com/jffp113/go-algorand-sdk/mnemonic"
 	"github.com/jffp113/go-algorand-sdk/types"
-	"github.com/satori/go.uuid"
+	"golang.org/x/crypto/ed25519"
 	"math/rand"
 	"strings"
+	"sync"
 	"time"
 )
 
-const Memmonic = "fiscal interest rare inhale fiscal apple piano body cricket will citizen emerge need view goose solve level obey trip asthma hero range call ability fruit"
-const FROM = "UOYMQREGR5RMAI3QBIJLUCFVNVFDH7CW66ITCOSKVUP5XGWIXCYNBVQ76A"
-const TO = "XLEXJVZ525GL7X24FD4DMWSERHG3EQ3MLF4SDGIGXAE7TPZTX4AC3PGVRE"
-const AMOUNT = 1000000
+//noteSize is the size of the random note of each transaction
+const noteSize = 16
+
+//Payment is the transaction sent by each request, it is
+//signed with the key of the sender account
+type Payment struct {
+	From   string
+	To     string
+	Amount uint64
+	key    ed25519.PrivateKey
+}
+
+//NewPayment reads the payment of the config, the sender
+//is the account of the mnemonic
+func NewPayment(c conf.AlgorandConfig) (Payment, error) {
+	key, err := mnemonic.ToPrivateKey(c.Mnemonic.Value())
+	if err != nil {
+		return Payment{}, fmt.Errorf("invalid mnemonic: %v", err)
+	}
+
+	var from types.Address
+	copy(from[:], key.Public().(ed25519.PublicKey))
+	return Payment{From: from.String(), To: c.Receiver, Amount: c.Amount, key: key}, nil
+}
 
 type AlgorandHandler struct {
-	// Create an algod client
+	//mu guards the clients and urls, which are replaced by Reconfigure
+	mu      sync.RWMutex
 	clients []algod.Client
+	urls    []string
+	payment Payment
 }
 
 func (h *AlgorandHandler) InitHandler(config conf.Configuration) {
-	for _,v := range config.Conf.ValidatorNodes {
-		algodClient, err := algod.MakeClient(fmt.Sprintf("http://%s",v), config.Conf.Token)
+	payment, err := NewPayment(config.Algorand)
+	if err != nil {
+		panic(err)
+	}
+	h.payment = payment
+
+	clients, err := NewClients(config.Algorand)
+	if err != nil {
+		panic(err)
+	}
+	h.clients = clients
+	h.urls = config.Algorand.Nodes
+}
+
+//Reconfigure sends the following transactions to the reloaded nodes
+func (h *AlgorandHandler) Reconfigure(config conf.Configuration) error {
+	clients, err := NewClients(config.Algorand)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients = clients
+	h.urls = config.Algorand.Nodes
+	return nil
+}
+
+//NewClients returns an algod client of each node of the section
+func NewClients(c conf.AlgorandConfig) ([]algod.Client, error) {
+	var clients []algod.Client
+	for _,v := range c.Nodes {
+		algodClient, err := algod.MakeClient(fmt.Sprintf("http://%s",v), c.Token.Value())
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
-
-		h.clients = append(h.clients,algodClient)
+		clients = append(clients,algodClient)
 	}
+	return clients, nil
+}
+
+//Reset drops the algod clients created by InitHandler
+func (h *AlgorandHandler) Reset() {
+	h.clients = nil
+	h.urls = nil
+	h.payment = Payment{}
 }
 
-func (h AlgorandHandler) DoRequest() Client.RequestStatus {
+func (h *AlgorandHandler) DoRequest(rnd *rand.Rand) Client.RequestStatus {
 	var stats Client.RequestStatus
 
-	_,cli := ChooseOne(h.clients)
+	h.mu.RLock()
+	i,cli := ChooseOne(h.clients, rnd)
+	stats.Node = h.urls[i]
+	h.mu.RUnlock()
 
 	stats.StartTime = time.Now()
-	err := performTransaction(cli)
+	err := performTransaction(cli, h.payment)
 	stats.EndTime = time.Now()
 
 	if err == nil {
 		stats.Success = true
 	}
+	stats.Err = err
+
+	return stats
+}
 
+//ValidateConfig requires a node to send the transactions to
+//and the payment settings
+func (h *AlgorandHandler) ValidateConfig(config conf.Configuration) []conf.Problem {
+	problems := conf.NotEmpty("algorand.nodes", config.Algorand.Nodes)
+	return append(problems, ValidatePayment("algorand", config.Algorand)...)
+}
+
+//ValidatePayment checks the payment settings of the Algorand
+//section at path
+func ValidatePayment(path string, c conf.AlgorandConfig) []conf.Problem {
+	var problems []conf.Problem
+	if !c.Mnemonic.IsSet() {
+		problems = append(problems, conf.Problemf(path+".mnemonic", "is empty"))
+	} else if _, err := mnemonic.ToPrivateKey(c.Mnemonic.Value()); err != nil {
+		problems = append(problems, conf.Problemf(path+".mnemonic", "is not a valid mnemonic: %v", err))
+	}
+	if _, err := types.DecodeAddress(c.Receiver); err != nil {
+		problems = append(problems, conf.Problemf(path+".receiver", "%q is not a valid address: %v", c.Receiver, err))
+	}
+	if c.Amount == 0 {
+		problems = append(problems, conf.Problemf(path+".amount", "must be at least 1 microAlgo"))
+	}
+	return problems
+}
+
+//Preflight checks that every node answers with the token
+//and that the sending account can pay for a transaction
+func (h *AlgorandHandler) Preflight(config conf.Configuration) []Client.CheckResult {
+	return PreflightNodes(config.Algorand)
+}
+
+//PreflightNodes checks the Algorand nodes and the balance of the sender
+func PreflightNodes(config conf.AlgorandConfig) []Client.CheckResult {
+	payment, err := NewPayment(config)
 	if err != nil {
-		fmt.Println(err)
+		return []Client.CheckResult{Client.NewCheck("algorand account", "", err)}
 	}
 
-	//fmt.Println(err)
+	var results []Client.CheckResult
+	for _,v := range config.Nodes {
+		name := fmt.Sprintf("algorand node %v", v)
+		c, err := algod.MakeClient(fmt.Sprintf("http://%s",v), config.Token.Value())
+		if err != nil {
+			results = append(results, Client.NewCheck(name, "", err))
+			continue
+		}
 
-	return stats
+		status, err := c.Status()
+		if err != nil {
+			if Client.ClassOf(err) != Client.ErrClassConnection {
+				err = fmt.Errorf("%v (check the token)", err)
+			}
+			results = append(results, Client.NewCheck(name, "", err))
+			continue
+		}
+		results = append(results, Client.NewCheck(name, fmt.Sprintf("last round %v", status.LastRound), nil))
+
+		account, err := c.AccountInformation(payment.From)
+		if err == nil && account.Amount < payment.Amount {
+			err = fmt.Errorf("balance of %v is %v microAlgos, below the amount of %v", payment.From, account.Amount, payment.Amount)
+		}
+		results = append(results, Client.NewCheck(fmt.Sprintf("balance on %v", v),
+			fmt.Sprintf("%v microAlgos", account.Amount), err))
+	}
+	return results
+}
+
+func init() {
+	Client.Register(Client.HandlerInfo{
+		Name:        "algorand",
+		Description: "Algorand payment transactions sent to a random node, waiting for confirmation",
+		ConfigKeys:  []string{"algorand.nodes", "algorand.token", "algorand.mnemonic", "algorand.receiver", "algorand.amount"},
+		ReloadKeys:  []string{"algorand.nodes"},
+		New:         NewHandler,
+	})
 }
 
 func NewHandler() Client.Handler {
 	return &AlgorandHandler{}
 }
 
-func performTransaction(c algod.Client) error{
+func performTransaction(c algod.Client, p Payment) error{
 
-	tx,err := CreateAlgoTransaction(c)
+	tx,err := CreateAlgoTransaction(c, p)
 
 	if err != nil {
 		return err
 	}
 
-	id,b,err :=SignTransaction(tx,types.GroupEnvelop{})
+	id,b,err :=SignTransaction(tx, p, types.GroupEnvelop{})
 
 	return SendTransaction(c,b,id)
 }
 
-func CreateAlgoTransaction(c algod.Client) (types.Transaction, error){
+//CreateAlgoTransaction creates a payment with a random note, the note
+//comes from crypto/rand and not the seeded stream so that runs with the
+//same seed do not send transactions the ledger already has
+func CreateAlgoTransaction(c algod.Client, p Payment) (types.Transaction, error){
 	params,err := c.BuildSuggestedParams()
 	if err != nil {
 		return types.Transaction{},err
 	}
 
-	uuid := uuid.NewV4()
-	return future.MakePaymentTxn(FROM, TO,AMOUNT,uuid.Bytes(),"",params)
+	note := make([]byte, noteSize)
+	if _, err := cryptorand.Read(note); err != nil {
+		return types.Transaction{},err
+	}
+	return future.MakePaymentTxn(p.From, p.To,p.Amount,note,"",params)
 }
 
-func SignTransaction(tx types.Transaction, g types.GroupEnvelop) (string, []byte, error){
-	pk,_ := mnemonic.ToPrivateKey(Memmonic)
-	return crypto.SignTransactionWithGroupSignature(pk,tx,g)
+//SignTransaction signs the transaction with the key of the sender
+func SignTransaction(tx types.Transaction, p Payment, g types.GroupEnvelop) (string, []byte, error){
+	return crypto.SignTransactionWithGroupSignature(p.key,tx,g)
 }
 
 func SendTransaction(c algod.Client,tx []byte, id string) error {
 	_, err := c.SendRawTransaction(tx)
 
 	if err != nil {
+		if strings.Contains(err.Error(), "pool") {
+			return Client.NewClassifiedError(Client.ErrClassPoolFull, err)
+		}
 		return err
 	}
 
@@ -103,10 +249,7 @@ func SendTransaction(c algod.Client,tx []byte, id string) error {
 	return err
 }
 
-func ChooseOne(v []algod.Client) (int, algod.Client) {
-	src := rand.NewSource(time.Now().UnixNano())
-	rnd := rand.New(src)
-
+func ChooseOne(v []algod.Client, rnd *rand.Rand) (int, algod.Client) {
 	pos := rnd.Intn(len(v))
 	return pos, v[pos]
 }
@@ -115,7 +258,6 @@ func ChooseOne(v []algod.Client) (int, algod.Client) {
 func waitForConfirmation(txID string, client *algod.Client, timeout uint64) (models.Transaction, error) {
 	var pt models.Transaction
 	if client == nil || txID == "" || timeout < 0 {
-		fmt.Printf("Bad arguments for waitForConfirmation")
 		var msg = errors.New("Bad arguments for waitForConfirmation")
 		return pt, msg
 
@@ -123,9 +265,7 @@ func waitForConfirmation(txID string, client *algod.Client, timeout uint64) (mod
 
 	status, err := client.Status()
 	if err != nil {
-		fmt.Printf("error getting algod status: %s\n", err)
-		var msg = errors.New(strings.Join([]string{"error getting algod status: "}, err.Error()))
-		return pt, msg
+		return pt, fmt.Errorf("error getting algod status: %v", err)
 	}
 	startRound := status.LastRound + 1
 	currentRound := startRound
@@ -134,20 +274,15 @@ func waitForConfirmation(txID string, client *algod.Client, timeout uint64) (mod
 
 		pt, err := client.PendingTransactionInformation(txID)
 		if err != nil {
-			fmt.Printf("error getting pending transaction: %s\n", err)
-			var msg = errors.New(strings.Join([]string{"error getting pending transaction: "}, err.Error()))
-			return pt, msg
+			return pt, fmt.Errorf("error getting pending transaction: %v", err)
 		}
 		if pt.ConfirmedRound > 0 {
-			//fmt.Printf("Transaction "+txID+" confirmed in round %d\n", pt.ConfirmedRound)
 			return pt, nil
 		}
 		if pt.PoolError != "" {
-			fmt.Printf("There was a pool error, then the transaction has been rejected!")
 			var msg = errors.New("There was a pool error, then the transaction has been rejected")
-			return pt, msg
+			return pt, Client.NewClassifiedError(Client.ErrClassRejected, msg)
 		}
-		//fmt.Printf("waiting for confirmation\n")
 		status, err = client.StatusAfterBlock(currentRound)
 		currentRound++
 	}
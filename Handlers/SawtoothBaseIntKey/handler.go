@@ -1,53 +1,108 @@
 package SawtoothBaseIntKey
 
 import (
+	"encoding/hex"
 	"fmt"
 	"github.com/jffp113/Thesis_Client/Client"
 	"github.com/jffp113/Thesis_Client/conf"
 	"math/rand"
+	"sync"
 	"time"
 )
 
 type sawtoothHandler struct {
+	//mu guards cli, which is replaced by Reconfigure
+	mu  sync.RWMutex
 	cli []IntkeyClient
 }
 
 func (h *sawtoothHandler) InitHandler(config conf.Configuration) {
+	cli, err := newClients(config)
+	if err != nil {
+		panic(err)
+	}
+	h.cli = cli
+	h.cli[0].Set("johny", 1, 1)
+}
+
+//Reconfigure sends the following requests to the reloaded validators
+func (h *sawtoothHandler) Reconfigure(config conf.Configuration) error {
+	cli, err := newClients(config)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	h.cli = cli
+	h.mu.Unlock()
+	return nil
+}
+
+//newClients returns a client of each validator
+func newClients(config conf.Configuration) ([]IntkeyClient, error) {
 	var cli []IntkeyClient
-	for _,url := range config.Conf.ValidatorNodes {
-		c, err := NewIntkeyClient(fmt.Sprintf("http://%v",url), "")
+	for _,url := range config.Sawtooth.ValidatorNodes {
+		c, err := NewIntkeyClient(fmt.Sprintf("http://%v",url), config.Sawtooth.SigningKey.Value())
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
 		cli = append(cli,c)
 	}
-	h.cli = cli
-	h.cli[0].Set("johny", 1, 1) //We should check if config ValidatorNodes is empty
+	return cli, nil
 }
 
-func (h sawtoothHandler) DoRequest() Client.RequestStatus {
+func (h *sawtoothHandler) DoRequest(rnd *rand.Rand) Client.RequestStatus {
 	var stats Client.RequestStatus
-	_,cli := chooseOne(h.cli)
+	h.mu.RLock()
+	_,cli := chooseOne(h.cli, rnd)
+	h.mu.RUnlock()
 	stats.StartTime = time.Now()
 	_, err := cli.Inc("johny", 1, 1000) //big number to wait forever
 	stats.EndTime = time.Now()
 	if err == nil {
 		stats.Success = true
-	} else {
-		fmt.Println(err)
 	}
+	stats.Err = err
+	stats.Node = cli.url
 
 	return stats
 }
 
+//ValidateConfig requires a validator, InitHandler sets the key on the first one
+func (h *sawtoothHandler) ValidateConfig(config conf.Configuration) []conf.Problem {
+	problems := conf.NotEmpty("sawtooth.validatorNodes", config.Sawtooth.ValidatorNodes)
+	if key := config.Sawtooth.SigningKey.Value(); key != "" {
+		if b, err := hex.DecodeString(key); err != nil || len(b) != 32 {
+			problems = append(problems, conf.Problemf("sawtooth.signingKey", "is not a hex secp256k1 private key"))
+		}
+	}
+	return problems
+}
+
+//Preflight checks that the REST API of every validator answers
+func (h *sawtoothHandler) Preflight(config conf.Configuration) []Client.CheckResult {
+	var results []Client.CheckResult
+	for _,url := range config.Sawtooth.ValidatorNodes {
+		results = append(results, Client.CheckHTTP(fmt.Sprintf("validator %v",url),
+			fmt.Sprintf("http://%v/blocks?limit=1",url)))
+	}
+	return results
+}
+
+func init() {
+	Client.Register(Client.HandlerInfo{
+		Name:        "sawtooth",
+		Description: "Sawtooth intkey increments signed by the client, sent to a random validator",
+		ConfigKeys:  []string{"sawtooth.validatorNodes", "sawtooth.signingKey"},
+		ReloadKeys:  []string{"sawtooth.validatorNodes"},
+		New:         NewHandler,
+	})
+}
+
 func NewHandler() Client.Handler {
 	return &sawtoothHandler{}
 }
 
-func chooseOne(v []IntkeyClient) (int, IntkeyClient) {
-	src := rand.NewSource(time.Now().UnixNano())
-	rnd := rand.New(src)
-
+func chooseOne(v []IntkeyClient, rnd *rand.Rand) (int, IntkeyClient) {
 	pos := rnd.Intn(len(v))
 	return pos, v[pos]
 }
\ No newline at end of file
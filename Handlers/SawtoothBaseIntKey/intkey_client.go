@@ -19,6 +19,7 @@ package SawtoothBaseIntKey
 
 import (
 	bytes2 "bytes"
+	"crypto/rand"
 	"crypto/sha512"
 	"encoding/base64"
 	"encoding/hex"
@@ -31,9 +32,7 @@ import (
 	"github.com/jffp113/Thesis_Client/Handlers/SawtoothBaseIntKey/protobuf/transaction_pb2"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
-	"math/rand"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -64,18 +63,19 @@ type IntkeyClient struct {
 	signer *signing.Signer
 }
 
-func NewIntkeyClient(url string, keyfile string) (IntkeyClient, error) {
+//NewIntkeyClient signs with the hex secp256k1 private key, or a
+//random key when it is empty
+func NewIntkeyClient(url string, privateKeyHex string) (IntkeyClient, error) {
 
 	var privateKey signing.PrivateKey
-	if keyfile != "" {
-		// Read private key file
-		privateKeyStr, err := ioutil.ReadFile(keyfile)
+	if privateKeyHex != "" {
+		privateKeyBytes, err := hex.DecodeString(privateKeyHex)
 		if err != nil {
 			return IntkeyClient{},
-				errors.New(fmt.Sprintf("Failed to read private key: %v", err))
+				errors.New(fmt.Sprintf("Failed to decode private key: %v", err))
 		}
 		// Get private key object
-		privateKey = signing.NewSecp256k1PrivateKey(privateKeyStr)
+		privateKey = signing.NewSecp256k1PrivateKey(privateKeyBytes)
 	} else {
 		privateKey = signing.NewSecp256k1Context().NewRandomPrivateKey()
 	}
@@ -232,6 +232,16 @@ func (intkeyClient IntkeyClient) sendRequest(
 	return string(reponseBody), nil
 }
 
+//newNonce draws the transaction nonce from crypto/rand instead of the
+//seeded stream, runs with the same seed must still send new transactions
+func newNonce() (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(nonce), nil
+}
+
 func (intkeyClient IntkeyClient) sendTransaction(
 	verb string, name string, value uint, wait uint) (string, error) {
 
@@ -248,13 +258,18 @@ func (intkeyClient IntkeyClient) sendTransaction(
 	// construct the address
 	address := intkeyClient.getAddress(name)
 
+	nonce, err := newNonce()
+	if err != nil {
+		return "", err
+	}
+
 	// Construct TransactionHeader
 	rawTransactionHeader := transaction_pb2.TransactionHeader{
 		SignerPublicKey:  intkeyClient.signer.GetPublicKey().AsHex(),
 		FamilyName:       FAMILY_NAME,
 		FamilyVersion:    FAMILY_VERSION,
 		Dependencies:     []string{}, // empty dependency list
-		Nonce:            strconv.Itoa(rand.Int()),
+		Nonce:            nonce,
 		BatcherPublicKey: intkeyClient.signer.GetPublicKey().AsHex(),
 		Inputs:           []string{address},
 		Outputs:          []string{address},
@@ -1 +1 @@
-CGO_CFLAGS="-I/usr/local/opt/openssl/include" CGO_LDFLAGS="-L/usr/local/opt/openssl/lib" go run  main.go -c 1 -a sawtooth
\ No newline at end of file
+CGO_CFLAGS="-I/usr/local/opt/openssl/include" CGO_LDFLAGS="-L/usr/local/opt/openssl/lib" go run . intkey -c 1
\ No newline at end of file
@@ -1,57 +1,138 @@
 package SawoothExtendedIntKey
 
 import (
+	"encoding/hex"
 	"fmt"
+	"github.com/jffp113/CryptoProviderSDK/keychain"
 	"github.com/jffp113/Thesis_Client/Client"
 	"github.com/jffp113/Thesis_Client/conf"
 	"math/rand"
+	"sync"
 	"time"
 )
 
 type sawtoothXHandler struct {
-	cli            IntkeyClient
+	cli IntkeyClient
+	//mu guards the nodes, which are replaced by Reconfigure
+	mu             sync.RWMutex
 	signerNodesURL []string
 	validatorURL   []string
 }
 
 func (h *sawtoothXHandler) InitHandler(config conf.Configuration) {
-	cli, err := NewIntkeyClient("", config.Conf.KeyPath, config.Conf.KeyName)
+	cli, err := NewIntkeyClient(config.SawtoothX.SigningKey.Value(), config.SawtoothX.KeyPath, config.SawtoothX.KeyName)
 
 	if err != nil {
 		panic(err)
 	}
-	h.signerNodesURL = config.Conf.SignerNodes
-	h.validatorURL = config.Conf.ValidatorNodes
+	h.signerNodesURL = config.SawtoothX.SignerNodes
+	h.validatorURL = config.SawtoothX.ValidatorNodes
 
 	h.cli = cli
 
-	i, validator := chooseOne(h.validatorURL)
+	rnd := Client.NewStream(config.Seed)
+	i, validator := chooseOne(h.validatorURL, rnd)
 	h.cli.Set("alice", 1, 1, validator, h.signerNodesURL[i])
 }
 
-func (h sawtoothXHandler) DoRequest() Client.RequestStatus {
+//Reconfigure sends the following requests to the reloaded
+//validators and their signer nodes
+func (h *sawtoothXHandler) Reconfigure(config conf.Configuration) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.signerNodesURL = config.SawtoothX.SignerNodes
+	h.validatorURL = config.SawtoothX.ValidatorNodes
+	return nil
+}
+
+func (h *sawtoothXHandler) DoRequest(rnd *rand.Rand) Client.RequestStatus {
 	var stats Client.RequestStatus
-	i, validator := chooseOne(h.validatorURL)
+	h.mu.RLock()
+	i, validator := chooseOne(h.validatorURL, rnd)
+	signer := h.signerNodesURL[i]
+	h.mu.RUnlock()
 	stats.StartTime = time.Now()
-	_, err := h.cli.Inc("alice", 1, 1000, validator, h.signerNodesURL[i]) //big number to wait forever
+	_, err := h.cli.Inc("alice", 1, 1000, validator, signer) //big number to wait forever
 	stats.EndTime = time.Now()
 	if err == nil {
 		stats.Success = true
-	} else {
-		fmt.Println(err)
 	}
+	stats.Err = err
+	stats.Node = validator
 
 	return stats
 }
 
-func chooseOne(v []string) (int, string) {
-	src := rand.NewSource(time.Now().UnixNano())
-	rnd := rand.New(src)
+//ValidateConfig checks that every validator has a signer node and
+//that the key name is consistent with the signernode scheme, n and t
+func (h *sawtoothXHandler) ValidateConfig(config conf.Configuration) []conf.Problem {
+	c, group := config.SawtoothX, config.SignerNode
+	problems := conf.NotEmpty("sawtoothX.validatorNodes", c.ValidatorNodes)
+
+	//validator i sends its batches to signer node i
+	if len(c.SignerNodes) < len(c.ValidatorNodes) {
+		problems = append(problems, conf.Problemf("sawtoothX.signerNodes",
+			"%v signer nodes for %v validators, each validator needs one", len(c.SignerNodes), len(c.ValidatorNodes)))
+	}
+
+	if key := c.SigningKey.Value(); key != "" {
+		if b, err := hex.DecodeString(key); err != nil || len(b) != 32 {
+			problems = append(problems, conf.Problemf("sawtoothX.signingKey", "is not a hex secp256k1 private key"))
+		}
+	}
+
+	if c.KeyPath == "" {
+		problems = append(problems, conf.Problemf("sawtoothX.keyPath", "is empty"))
+	}
+
+	scheme, n, t, err := conf.ParseKeyName(c.KeyName)
+	switch {
+	case err != nil:
+		problems = append(problems, conf.Problemf("sawtoothX.keyName", "%q is not of the form <scheme>_<n>_<t>", c.KeyName))
+	case t < 1 || t > n:
+		problems = append(problems, conf.Problemf("sawtoothX.keyName", "t of %v must be between 1 and n", c.KeyName))
+	case group.Scheme != "" && group.N > 0 && group.T > 0 && (scheme != group.Scheme || n != group.N || t != group.T):
+		problems = append(problems, conf.Problemf("sawtoothX.keyName", "%v does not match signernode.scheme, signernode.n and signernode.t (%v_%v_%v)",
+			c.KeyName, group.Scheme, group.N, group.T))
+	}
+	return problems
+}
+
+//Preflight checks the validators and their signer nodes and
+//that the group public key loads from the keychain
+func (h *sawtoothXHandler) Preflight(config conf.Configuration) []Client.CheckResult {
+	validators, signers := config.SawtoothX.ValidatorNodes, config.SawtoothX.SignerNodes
+	var results []Client.CheckResult
+	for _, url := range validators {
+		results = append(results, Client.CheckHTTP(fmt.Sprintf("validator %v", url),
+			fmt.Sprintf("http://%v/blocks?limit=1", url)))
+	}
+	for _, url := range signers {
+		results = append(results, Client.CheckDial(fmt.Sprintf("signer node %v", url), url))
+	}
 
+	kc := keychain.NewKeyChain(config.SawtoothX.KeyPath)
+	_, err := kc.LoadPublicKey(config.SawtoothX.KeyName)
+	results = append(results, Client.NewCheck("key "+config.SawtoothX.KeyName,
+		fmt.Sprintf("loaded from %v", config.SawtoothX.KeyPath), err))
+	return results
+}
+
+func chooseOne(v []string, rnd *rand.Rand) (int, string) {
 	pos := rnd.Intn(len(v))
 	return pos, v[pos]
 }
 
+func init() {
+	Client.Register(Client.HandlerInfo{
+		Name:        "sawtoothX",
+		Description: "Sawtooth intkey increments with a group signature from the signer node paired with a random validator",
+		ConfigKeys:  []string{"sawtoothX.validatorNodes", "sawtoothX.signerNodes", "sawtoothX.keyPath", "sawtoothX.keyName", "sawtoothX.signingKey"},
+		ReloadKeys:  []string{"sawtoothX.validatorNodes", "sawtoothX.signerNodes"},
+		New:         NewHandler,
+	})
+}
+
 func NewHandler() Client.Handler {
 	return &sawtoothXHandler{}
 }
@@ -19,6 +19,7 @@ package SawoothExtendedIntKey
 
 import (
 	"bytes"
+	"crypto/rand"
 	"crypto/sha512"
 	"encoding/base64"
 	"encoding/hex"
@@ -33,9 +34,7 @@ import (
 	"github.com/jffp113/Thesis_Client/Handlers/SawtoothExtendedIntKey/pb"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
-	"math/rand"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -67,18 +66,19 @@ type IntkeyClient struct {
 	keyName string
 }
 
-func NewIntkeyClient(keyfile string, keyPath string, keyName string) (IntkeyClient, error) {
+//NewIntkeyClient signs with the hex secp256k1 private key, or a
+//random key when it is empty
+func NewIntkeyClient(privateKeyHex string, keyPath string, keyName string) (IntkeyClient, error) {
 
 	var privateKey signing.PrivateKey
-	if keyfile != "" {
-		// Read private key file
-		privateKeyStr, err := ioutil.ReadFile(keyfile)
+	if privateKeyHex != "" {
+		privateKeyBytes, err := hex.DecodeString(privateKeyHex)
 		if err != nil {
 			return IntkeyClient{},
-				errors.New(fmt.Sprintf("Failed to read private key: %v", err))
+				errors.New(fmt.Sprintf("Failed to decode private key: %v", err))
 		}
 		// Get private key object
-		privateKey = signing.NewSecp256k1PrivateKey(privateKeyStr)
+		privateKey = signing.NewSecp256k1PrivateKey(privateKeyBytes)
 	} else {
 		privateKey = signing.NewSecp256k1Context().NewRandomPrivateKey()
 	}
@@ -236,6 +236,16 @@ func (intkeyClient IntkeyClient) sendRequest(
 	return string(reponseBody), nil
 }
 
+//newNonce draws the transaction nonce from crypto/rand instead of the
+//seeded stream, runs with the same seed must still send new transactions
+func newNonce() (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(nonce), nil
+}
+
 func (intkeyClient IntkeyClient) sendTransaction(
 	verb string, name string, value uint, wait uint, validatorURL string, signerURL string) (string, error) {
 
@@ -252,13 +262,18 @@ func (intkeyClient IntkeyClient) sendTransaction(
 	// construct the address
 	address := intkeyClient.getAddress(name)
 
+	nonce, err := newNonce()
+	if err != nil {
+		return "", err
+	}
+
 	// Construct TransactionHeader
 	rawTransactionHeader := transaction_pb2.TransactionHeader{
 		SignerPublicKey:  intkeyClient.signer.GetPublicKey().AsHex(),
 		FamilyName:       FAMILY_NAME,
 		FamilyVersion:    FAMILY_VERSION,
 		Dependencies:     []string{}, // empty dependency list
-		Nonce:            strconv.Itoa(rand.Int()),
+		Nonce:            nonce,
 		BatcherPublicKey: intkeyClient.signer.GetPublicKey().AsHex(),
 		Inputs:           []string{address},
 		Outputs:          []string{address},
@@ -10,10 +10,15 @@ import (
 	"github.com/jffp113/go-algorand-sdk/client/algod"
 	"github.com/jffp113/go-algorand-sdk/encoding/msgpack"
 	"github.com/jffp113/go-algorand-sdk/types"
+	"math/rand"
+	"strings"
+	"sync"
 	"time"
 )
 
 type signerNode struct {
+	//mu guards signerNodesURL and clients, which are replaced by Reconfigure
+	mu             sync.RWMutex
 	signerNodesURL []string
 
 	//Permissionless Settings
@@ -28,22 +33,24 @@ type signerNode struct {
 
 	//If incorporating with algo
 	clients []algod.Client
+	payment Algorand.Payment
 	sendToAlgorand bool
 }
 
 func (h *signerNode) InitHandler(config conf.Configuration) {
-	h.signerNodesURL = config.Conf.SignerNodes
-	h.IsPermissionless = config.Conf.IsPermissionless
-	h.IsOneTimeKey = config.Conf.IsOneTimeKey
-	h.IsGroupRandomGenerated = config.Conf.IsGroupRandomGenerated
-	h.N = config.Conf.N
-	h.T = config.Conf.T
-	h.Scheme = config.Conf.Scheme
-	h.sendToAlgorand = config.Conf.SendSignatureToAlgorand
+	c := config.SignerNode
+	h.signerNodesURL = c.SignerNodes
+	h.IsPermissionless = c.IsPermissionless
+	h.IsOneTimeKey = c.IsOneTimeKey
+	h.IsGroupRandomGenerated = c.IsGroupRandomGenerated
+	h.N = c.N
+	h.T = c.T
+	h.Scheme = c.Scheme
+	h.sendToAlgorand = c.SendSignatureToAlgorand
 
 
 	if h.IsPermissionless && !h.IsOneTimeKey{
-		key,err := h.InstallKey()
+		key,err := h.InstallKey(Client.NewStream(config.Seed))
 		h.key = key
 		if err != nil {
 			panic(err)
@@ -53,29 +60,67 @@ func (h *signerNode) InitHandler(config conf.Configuration) {
 
 	//Parse algorand Validators
 	if h.sendToAlgorand {
-		for _,v := range config.Conf.ValidatorNodes {
-			algodClient, err := algod.MakeClient(fmt.Sprintf("http://%s",v), config.Conf.Token)
-			if err != nil {
-				panic(err)
-			}
+		payment, err := Algorand.NewPayment(c.Algorand)
+		if err != nil {
+			panic(err)
+		}
+		h.payment = payment
+
+		clients, err := Algorand.NewClients(c.Algorand)
+		if err != nil {
+			panic(err)
+		}
+		h.clients = clients
+	}
+}
 
-			h.clients = append(h.clients,algodClient)
+
+//Reconfigure sends the following requests to the reloaded signer
+//and Algorand nodes, an installed key keeps its group membership
+func (h *signerNode) Reconfigure(config conf.Configuration) error {
+	var clients []algod.Client
+	if h.sendToAlgorand {
+		var err error
+		if clients, err = Algorand.NewClients(config.SignerNode.Algorand); err != nil {
+			return err
 		}
 	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.signerNodesURL = config.SignerNode.SignerNodes
+	h.clients = clients
+	return nil
+}
+
+//nodes returns the signer nodes the requests are sent to
+func (h *signerNode) nodes() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.signerNodesURL
 }
 
+//Reset drops the installed key and the algod clients so that
+//InitHandler installs a fresh key
+func (h *signerNode) Reset() {
+	h.key = nil
+	h.clients = nil
+	h.payment = Algorand.Payment{}
+}
 
-func (h *signerNode) InstallKey() (*client.Key,error) {
+//InstallKey generates a key and installs its shares, a random
+//group membership is drawn from rnd
+func (h *signerNode) InstallKey(rnd *rand.Rand) (*client.Key,error) {
 	cli := client.NewPermissionlessClient()
 	var membership []string
 
 	if h.IsGroupRandomGenerated {
-		membership = client.GetSubsetMembership(h.signerNodesURL,h.N)
+		membership = subsetMembership(h.nodes(),h.N,rnd)
 	} else{
-		membership = client.GetNNearestNodes(h.signerNodesURL,h.N)
+		membership = client.GetNNearestNodes(h.nodes(),h.N)
 	}
 
-	gen := getKeyGen(h.Scheme)
+	gen := KeyGenerator(h.Scheme)
 	pub, priv := gen.Gen(h.N,h.T)
 
 	key := client.Key{
@@ -93,28 +138,111 @@ func (h *signerNode) InstallKey() (*client.Key,error) {
 	return &key,err
 }
 
-func (h *signerNode) DoRequest() Client.RequestStatus {
+func (h *signerNode) DoRequest(rnd *rand.Rand) Client.RequestStatus {
 	var stats Client.RequestStatus
 
 	if !h.IsPermissionless{
-		err := performPermissionedTransaction(h.signerNodesURL,&stats)
+		urls := h.nodes()
+		//the permissioned client gets every signer node, record the first one
+		if len(urls) > 0 {
+			stats.Node = urls[0]
+		}
+		err := performPermissionedTransaction(urls,&stats)
 		if err == nil {
 			stats.Success = true
 		}
+		stats.Err = err
 	}else{
-		err := performPermissionlessTransaction(h,&stats)
+		err := performPermissionlessTransaction(h,&stats,rnd)
 		if err == nil {
 			stats.Success = true
 		}
+		stats.Err = err
+	}
+
+	//Failures before the request is sent leave the times unset
+	if stats.StartTime.IsZero() {
+		stats.StartTime = time.Now()
+	}
+	if stats.EndTime.IsZero() {
+		stats.EndTime = time.Now()
 	}
 
 	return stats
 }
 
+func init() {
+	Client.Register(Client.HandlerInfo{
+		Name:        "signernode",
+		Description: "Threshold signature requests to the signer nodes, permissioned or permissionless, optionally sent on to Algorand",
+		ConfigKeys:  []string{"signernode.signerNodes", "signernode.isPermissionless", "signernode.isOneTimeKey", "signernode.isGroupRandomGenerated", "signernode.n", "signernode.t", "signernode.scheme", "signernode.sendSignatureToAlgorand", "signernode.algorand.nodes", "signernode.algorand.token", "signernode.algorand.mnemonic", "signernode.algorand.receiver", "signernode.algorand.amount"},
+		ReloadKeys:  []string{"signernode.signerNodes", "signernode.algorand.nodes"},
+		New:         NewHandler,
+	})
+}
+
 func NewHandler() Client.Handler {
 	return &signerNode{}
 }
 
+//ValidateConfig checks that the scheme is supported and that the
+//group fits in the signer nodes when keys are generated by the client
+func (h *signerNode) ValidateConfig(config conf.Configuration) []conf.Problem {
+	c := config.SignerNode
+	problems := conf.NotEmpty("signernode.signerNodes", c.SignerNodes)
+
+	if c.IsPermissionless {
+		if KeyGenerator(c.Scheme) == nil {
+			problems = append(problems, conf.Problemf("signernode.scheme", "unsupported scheme %q", c.Scheme))
+		}
+		switch {
+		case c.N < 1:
+			problems = append(problems, conf.Problemf("signernode.n", "must be at least 1, got %v", c.N))
+		case c.N > len(c.SignerNodes):
+			problems = append(problems, conf.Problemf("signernode.n", "%v is larger than the %v signerNodes", c.N, len(c.SignerNodes)))
+		}
+		if c.T < 1 || c.T > c.N {
+			problems = append(problems, conf.Problemf("signernode.t", "must be between 1 and n (%v), got %v", c.N, c.T))
+		}
+	}
+
+	if c.SendSignatureToAlgorand {
+		if len(c.Algorand.Nodes) == 0 {
+			problems = append(problems, conf.Problemf("signernode.algorand.nodes", "is empty, sendSignatureToAlgorand needs the Algorand nodes"))
+		}
+		problems = append(problems, Algorand.ValidatePayment("signernode.algorand", c.Algorand)...)
+	}
+	return problems
+}
+
+//Preflight checks that every signer node accepts connections and,
+//when signatures are sent to Algorand, the Algorand nodes
+func (h *signerNode) Preflight(config conf.Configuration) []Client.CheckResult {
+	c := config.SignerNode
+	var results []Client.CheckResult
+	for _, url := range c.SignerNodes {
+		results = append(results, Client.CheckDial(fmt.Sprintf("signer node %v", url), url))
+	}
+
+	if c.SendSignatureToAlgorand {
+		results = append(results, Algorand.PreflightNodes(c.Algorand)...)
+	}
+	return results
+}
+
+//subsetMembership picks n distinct signer nodes, it replaces
+//client.GetSubsetMembership which draws from an unseeded source
+func subsetMembership(urls []string, n int, rnd *rand.Rand) []string {
+	if n > len(urls) {
+		n = len(urls)
+	}
+	membership := make([]string, n)
+	for i, pos := range rnd.Perm(len(urls))[:n] {
+		membership[i] = urls[pos]
+	}
+	return membership
+}
+
 func performPermissionedTransaction(urls []string,stats *Client.RequestStatus) error {
 	c ,err := client.NewPermissionedClient(client.SetSignerNodeAddresses(urls...))
 
@@ -125,7 +253,16 @@ func performPermissionedTransaction(urls []string,stats *Client.RequestStatus) e
 	stats.StartTime = time.Now()
 	_, err = c.SendSignRequest([]byte("Hello"),"intkey")
 	stats.EndTime = time.Now()
-	return err
+	return classifySignError(err)
+}
+
+//classifySignError marks errors from a sign request that are
+//not connection problems as rejections by the signer nodes
+func classifySignError(err error) error {
+	if err == nil || Client.ClassOf(err) != Client.ErrClassOther {
+		return err
+	}
+	return Client.NewClassifiedError(Client.ErrClassRejected, err)
 }
 
 //performPermissionlessTransaction does to many things.
@@ -133,7 +270,7 @@ func performPermissionedTransaction(urls []string,stats *Client.RequestStatus) e
 //Next if one time key is activated it generates a key on the fly installs
 //Only next asks for a group signature over the data, depending on the flags it can happen
 //over a algorand transaction or over the bytes "hello".
-func performPermissionlessTransaction(h *signerNode,stats *Client.RequestStatus) error {
+func performPermissionlessTransaction(h *signerNode,stats *Client.RequestStatus,rnd *rand.Rand) error {
 	c  := client.NewPermissionlessClient()
 	var err error
 	var tx types.Transaction
@@ -143,11 +280,12 @@ func performPermissionlessTransaction(h *signerNode,stats *Client.RequestStatus)
 
 	stats.StartTime = time.Now()
 
-	//fmt.Println(h.sendToAlgorand)
 	//If we are sending to algorand, should change bytes to sign
 	if h.sendToAlgorand {
-		_,algoCli = Algorand.ChooseOne(h.clients)
-		tx,err = Algorand.CreateAlgoTransaction(algoCli)
+		h.mu.RLock()
+		_,algoCli = Algorand.ChooseOne(h.clients,rnd)
+		h.mu.RUnlock()
+		tx,err = Algorand.CreateAlgoTransaction(algoCli,h.payment)
 
 		if err != nil {
 			return err
@@ -159,23 +297,26 @@ func performPermissionlessTransaction(h *signerNode,stats *Client.RequestStatus)
 	}
 
 	if h.IsOneTimeKey {
-		key, err = h.InstallKey()
+		key, err = h.InstallKey(rnd)
 		if err != nil {
-			fmt.Println(err)
 			return err
 		}
 	}
+	//the group of the key serves the request
+	if key != nil {
+		stats.Node = strings.Join(key.GroupMembership, ",")
+	}
 	sig, err := c.SendSignRequest(contentToSign,"intkey",key)
 
 	if err != nil {
-		//fmt.Println(err)
-		return nil
+		stats.EndTime = time.Now()
+		return classifySignError(err)
 	}
 
 	//Creating signed msg to send to algorand
 	if h.sendToAlgorand {
 		pub, _ :=  key.PubKey.MarshalBinary()
-		id, b, err := Algorand.SignTransaction(tx,types.GroupEnvelop{
+		id, b, err := Algorand.SignTransaction(tx,h.payment,types.GroupEnvelop{
 			PublicKey: pub,
 			Signature: sig.Signature,
 			Scheme:    sig.Scheme,
@@ -6,8 +6,9 @@ import (
 	"github.com/jffp113/CryptoProviderSDK/example/handlers/trsa"
 )
 
-//Used to get the correct key generator
-func getKeyGen(scheme string) crypto.KeyShareGenerator {
+//KeyGenerator returns the key share generator of a scheme,
+//nil if the scheme is not supported
+func KeyGenerator(scheme string) crypto.KeyShareGenerator {
 	switch scheme {
 	case "TBLS256Optimistic":
 		fallthrough
@@ -1,32 +1,222 @@
 package SimpleHttp
 
 import (
+	"bytes"
+	"fmt"
 	"github.com/jffp113/Thesis_Client/Client"
 	"github.com/jffp113/Thesis_Client/conf"
+	"io"
+	"io/ioutil"
+	"math/rand"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 )
 
+//defaultURL is the target when the http section sets no urls
+const defaultURL = "https://google.com/"
+
+//checkTimeout bounds the request sent to each target by Preflight
+const checkTimeout = 3 * time.Second
+
 type httpHandler struct {
+	urls     []string
+	method   string
+	headers  http.Header
+	host     string
+	body     []byte
+	expected map[int]bool
 }
 
-func (h httpHandler) InitHandler(config conf.Configuration) {
-	//TODO here you should parse config
-	//For example parse config to get url, tls settings, etc,..
+func (h *httpHandler) InitHandler(config conf.Configuration) {
+	if err := h.configure(config.HTTP); err != nil {
+		panic(err)
+	}
+}
+
+//configure prepares the request of the section
+func (h *httpHandler) configure(c conf.HTTPConfig) error {
+	h.urls = targets(c)
+	h.method = method(c)
+
+	h.headers = make(http.Header)
+	h.host = ""
+	for k, v := range c.Headers {
+		if strings.EqualFold(k, "Host") {
+			h.host = v.Value()
+			continue
+		}
+		h.headers.Set(k, v.Value())
+	}
+
+	body, err := requestBody(c)
+	if err != nil {
+		return err
+	}
+	h.body = body
+
+	h.expected = make(map[int]bool)
+	for _, status := range c.ExpectedStatus {
+		h.expected[status] = true
+	}
+	return nil
 }
 
-func (h httpHandler) DoRequest() Client.RequestStatus {
+func (h *httpHandler) DoRequest(rnd *rand.Rand) Client.RequestStatus {
 	var stats Client.RequestStatus
+	target := h.urls[rnd.Intn(len(h.urls))]
+	stats.Node = target
+
+	req, err := h.newRequest(target)
+	if err != nil {
+		stats.StartTime = time.Now()
+		stats.EndTime = stats.StartTime
+		stats.Err = err
+		return stats
+	}
+
 	stats.StartTime = time.Now()
-	_, err := http.Get("https://google.com/")
+	_, err = h.send(http.DefaultClient, req)
 	stats.EndTime = time.Now()
+
 	if err == nil {
 		stats.Success = true
 	}
+	stats.Err = err
 
 	return stats
 }
 
+//newRequest returns the configured request to target
+func (h *httpHandler) newRequest(target string) (*http.Request, error) {
+	req, err := http.NewRequest(h.method, target, bytes.NewReader(h.body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = h.headers.Clone()
+	if h.host != "" {
+		req.Host = h.host
+	}
+	return req, nil
+}
+
+//send sends the request and returns the status of the response,
+//a status that is not expected is an error
+func (h *httpHandler) send(client *http.Client, req *http.Request) (string, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	//the response is only complete once its body is read
+	_, err = io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return resp.Status, err
+	}
+
+	if !h.accepts(resp.StatusCode) {
+		return resp.Status, Client.NewClassifiedError(Client.ErrClassRejected,
+			fmt.Errorf("%v answered %v", req.URL, resp.Status))
+	}
+	return resp.Status, nil
+}
+
+//accepts reports if the status is expected, any 2xx status
+//is when no status is configured
+func (h *httpHandler) accepts(status int) bool {
+	if len(h.expected) == 0 {
+		return status >= 200 && status < 300
+	}
+	return h.expected[status]
+}
+
+func init() {
+	Client.Register(Client.HandlerInfo{
+		Name:        "http",
+		Description: "HTTP requests to a random url of the http section (default GET https://google.com/), a baseline of the client itself",
+		ConfigKeys:  []string{"http.urls", "http.method", "http.headers", "http.body", "http.bodyFile", "http.expectedStatus"},
+		New:         NewHandler,
+	})
+}
+
+//ValidateConfig checks the urls, the method, the body and
+//the expected status codes
+func (h *httpHandler) ValidateConfig(config conf.Configuration) []conf.Problem {
+	c := config.HTTP
+	var problems []conf.Problem
+	for i, target := range c.URLs {
+		u, err := url.Parse(target)
+		switch {
+		case err != nil:
+			problems = append(problems, conf.Problemf(fmt.Sprintf("http.urls[%v]", i), "%v", err))
+		case u.Scheme != "http" && u.Scheme != "https":
+			problems = append(problems, conf.Problemf(fmt.Sprintf("http.urls[%v]", i), "%q is not an http or https url", target))
+		}
+	}
+
+	if _, err := http.NewRequest(method(c), defaultURL, nil); err != nil {
+		problems = append(problems, conf.Problemf("http.method", "%q is not a valid method", c.Method))
+	}
+
+	if c.Body != "" && c.BodyFile != "" {
+		problems = append(problems, conf.Problemf("http.bodyFile", "is set together with http.body, set only one"))
+	} else if _, err := requestBody(c); err != nil {
+		problems = append(problems, conf.Problemf("http.bodyFile", "%v", err))
+	}
+
+	for i, status := range c.ExpectedStatus {
+		if status < 100 || status > 599 {
+			problems = append(problems, conf.Problemf(fmt.Sprintf("http.expectedStatus[%v]", i), "%v is not an HTTP status code", status))
+		}
+	}
+	return problems
+}
+
+//Preflight sends the configured request once to every target
+//and checks that it answers with an expected status
+func (h *httpHandler) Preflight(config conf.Configuration) []Client.CheckResult {
+	check := &httpHandler{}
+	if err := check.configure(config.HTTP); err != nil {
+		return []Client.CheckResult{Client.NewCheck("http request", "", err)}
+	}
+
+	client := &http.Client{Timeout: checkTimeout}
+	var results []Client.CheckResult
+	for _, target := range check.urls {
+		status := ""
+		req, err := check.newRequest(target)
+		if err == nil {
+			status, err = check.send(client, req)
+		}
+		results = append(results, Client.NewCheck(fmt.Sprintf("target %v", target),
+			fmt.Sprintf("%v answered %v", check.method, status), err))
+	}
+	return results
+}
+
 func NewHandler() Client.Handler {
-	return httpHandler{}
+	return &httpHandler{}
+}
+
+func targets(c conf.HTTPConfig) []string {
+	if len(c.URLs) == 0 {
+		return []string{defaultURL}
+	}
+	return c.URLs
+}
+
+func method(c conf.HTTPConfig) string {
+	if c.Method == "" {
+		return http.MethodGet
+	}
+	return strings.ToUpper(c.Method)
+}
+
+//requestBody returns the body of the section, read from the body file if set
+func requestBody(c conf.HTTPConfig) ([]byte, error) {
+	if c.BodyFile != "" {
+		return ioutil.ReadFile(c.BodyFile)
+	}
+	return []byte(c.Body), nil
 }
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"github.com/jffp113/Thesis_Client/conf"
+)
+
+type algorandCommand struct {
+	Nodes []string `long:"node" description:"Address of an Algorand node, repeat for each node (default: algorand.nodes)"`
+	//Token is left out of the options saved with the result
+	Token string `long:"token" json:"-" description:"API token of the Algorand nodes (default: algorand.token)"`
+	benchmarkOptions
+}
+
+func (c *algorandCommand) Execute(args []string) error {
+	return c.run("algorand", c, func(config *conf.Configuration) {
+		if len(c.Nodes) > 0 {
+			config.Algorand.Nodes = c.Nodes
+		}
+		if c.Token != "" {
+			config.Algorand.Token = conf.NewSecret(c.Token)
+		}
+	})
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"github.com/jffp113/Thesis_Client/Client"
+	"github.com/jffp113/Thesis_Client/conf"
+)
+
+type checkCommand struct {
+	Handler string `short:"a" long:"handler" default:"http" description:"Handler to check (see the handlers command)"`
+}
+
+func (c *checkCommand) Execute(args []string) error {
+	config, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	return runChecks(c.Handler, config)
+}
+
+//runChecks runs the preflight checks of the handler and
+//fails if any check fails
+func runChecks(handlerName string, config conf.Configuration) error {
+	results, err := Client.Preflight(handlerName, config)
+	if err != nil {
+		return err
+	}
+
+	if failed := Client.PrintChecks(results); failed > 0 {
+		return fmt.Errorf("%v of %v checks failed", failed, len(results))
+	}
+	fmt.Printf("All %v checks passed\n", len(results))
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"github.com/jffp113/Thesis_Client/Client"
+	"os"
+	"text/tabwriter"
+)
+
+type compareCommand struct {
+	Threshold        float64   `long:"threshold" default:"5" description:"Change in percent above which a significant slowdown is flagged as a regression"`
+	Alpha            float64   `long:"alpha" default:"0.05" description:"Significance level"`
+	Iterations       int       `long:"iterations" default:"500" description:"Bootstrap iterations for the confidence intervals"`
+	Percentiles      []float64 `long:"percentile" description:"Latency percentile to compare, can be repeated (default: 50, 90, 99)"`
+	FailOnRegression bool      `long:"fail-on-regression" description:"Exit with an error if a regression is found"`
+
+	Args struct {
+		Old string `positional-arg-name:"old" description:"Result file or stored run id"`
+		New string `positional-arg-name:"new" description:"Result file or stored run id"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+func (c *compareCommand) Execute(args []string) error {
+	old, err := loadResultRef(c.Args.Old)
+	if err != nil {
+		return err
+	}
+	new, err := loadResultRef(c.Args.New)
+	if err != nil {
+		return err
+	}
+
+	o := Client.DefaultCompareOptions()
+	o.Threshold = c.Threshold / 100
+	o.Alpha = c.Alpha
+	o.Iterations = c.Iterations
+	if len(c.Percentiles) > 0 {
+		o.Percentiles = c.Percentiles
+	}
+
+	fmt.Printf("old: %v\n", describeRun(old))
+	fmt.Printf("new: %v\n\n", describeRun(new))
+
+	regressions := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "metric\told\tnew\tdelta\t%.0f%% CI\tp\t\n", (1-o.Alpha)*100)
+	for _, cmp := range Client.Compare(old.Stats, new.Stats, o) {
+		verdict := "~"
+		switch {
+		case cmp.Regression(o):
+			verdict = "REGRESSION"
+			regressions++
+		case cmp.Improvement(o):
+			verdict = "improvement"
+		case cmp.Significant(o.Alpha):
+			verdict = "changed"
+		}
+
+		fmt.Fprintf(w, "%v\t%.2f %v\t%.2f %v\t%+.2f%%\t[%+.2f%%, %+.2f%%]\tp=%.3f\t%v\n",
+			cmp.Metric, cmp.Old, cmp.Unit, cmp.New, cmp.Unit,
+			cmp.Delta*100, cmp.Low*100, cmp.High*100, cmp.P, verdict)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if regressions > 0 && c.FailOnRegression {
+		return fmt.Errorf("%v regressions beyond %v%%", regressions, c.Threshold)
+	}
+	return nil
+}
+
+//loadResultRef loads a result from a file or, if no such file
+//exists, from the store by run id
+func loadResultRef(ref string) (Client.Result, error) {
+	if _, err := os.Stat(ref); err == nil {
+		return Client.LoadResult(ref)
+	}
+
+	store, err := openStore()
+	if err != nil {
+		return Client.Result{}, err
+	}
+	return store.Load(ref)
+}
+
+func describeRun(r Client.Result) string {
+	m := r.Metadata
+	desc := fmt.Sprintf("%v %v, %v clients, %v", m.RunID, m.Handler, m.Concurrency, m.Duration)
+	if scheme := m.Config.Group().Scheme; scheme != "" {
+		desc += ", " + scheme
+	}
+	return desc
+}
@@ -1,42 +1,152 @@
 package conf
 
 import (
-	"gopkg.in/yaml.v3"
 	"io/ioutil"
+	"time"
 )
 
 type Configuration struct {
-	Conf struct {
-		SignerNodes    []string `yaml:"signerNodes"`
-		ValidatorNodes []string `yaml:"validatorNodes"`
+	//Settings of each handler, see sections.go
+	Sawtooth   SawtoothConfig   `yaml:"sawtooth"`
+	SawtoothX  SawtoothXConfig  `yaml:"sawtoothX"`
+	SignerNode SignerNodeConfig `yaml:"signernode"`
+	Algorand   AlgorandConfig   `yaml:"algorand"`
+	HTTP       HTTPConfig       `yaml:"http"`
 
-		KeyName        string   `yaml:"keyName"`
-		KeyPath        string   `yaml:"keyPath"`
-		Token			string  `yaml:"token"`
+	//Conf is the flat section of the results of older versions, it
+	//is only read from JSON, see legacyKeys for config files
+	Conf *legacyConf `yaml:"-" json:",omitempty"`
 
+	//Retry policies indexed by handler name
+	Retry map[string]RetryPolicy `yaml:"retry"`
 
-		//Signernode permissionless settings
-		IsPermissionless  bool	`yaml:"isPermissionless"`
-		IsOneTimeKey	bool	`yaml:"isOneTimeKey"`
-		IsGroupRandomGenerated bool `yaml:"isGrupoRandomGenerated"`
-		N int `yaml:"n"`
-		T int `yaml:"t"`
-		Scheme string `yaml:"scheme"`
+	Pacing Pacing `yaml:"pacing"`
 
-		SendSignatureToAlgorand bool `yaml:"sendSignatureToAlgorand"`
+	//Keystore is the directory read by the keystore: references,
+	//the default is ~/.thesis_client/keystore
+	Keystore string `yaml:"keystore"`
 
+	//Seed of every random choice of the run, 0 picks one
+	//from the clock, the seed used is saved with the result
+	Seed int64 `yaml:"seed"`
+
+	//Profile is the name of the profile the configuration was
+	//parsed with, empty for the top level configuration
+	Profile string `yaml:"-"`
+	//Overrides are the keys replaced by environment variables
+	//and command line settings, in the order they were applied
+	Overrides []Override `yaml:"-"`
+}
+
+//Pacing controls how each worker spaces its requests
+type Pacing struct {
+	ThinkTime ThinkTime `yaml:"thinkTime"`
+	//Rate limits the requests per second of each worker, 0 means no limit
+	Rate float64 `yaml:"rate"`
+}
+
+//ThinkTime is the pause a worker takes after each request.
+//Distribution is one of constant, uniform, exponential or file.
+type ThinkTime struct {
+	Distribution string `yaml:"distribution"`
+	//Value is the constant pause or the mean of the exponential distribution
+	Value time.Duration `yaml:"value"`
+	//Min and Max bound the uniform distribution
+	Min time.Duration `yaml:"min"`
+	Max time.Duration `yaml:"max"`
+	//File holds one duration per line, pauses are drawn from its values
+	File string `yaml:"file"`
+}
+
+//RetryPolicy describes how failed requests of a handler are retried.
+//The zero value disables retries.
+type RetryPolicy struct {
+	MaxAttempts    int           `yaml:"maxAttempts"`
+	InitialBackoff time.Duration `yaml:"initialBackoff"`
+	MaxBackoff     time.Duration `yaml:"maxBackoff"`
+	Multiplier     float64       `yaml:"multiplier"`
+	//Jitter is the fraction of the backoff that is randomized (0 to 1)
+	Jitter float64 `yaml:"jitter"`
+	//RetryOn lists the error classes that are retried, empty means all
+	RetryOn []string `yaml:"retryOn"`
+}
+
+//Attempts returns the total number of attempts allowed per request
+func (p RetryPolicy) Attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+//ShouldRetry reports if errors of the given class are retried
+func (p RetryPolicy) ShouldRetry(class string) bool {
+	if len(p.RetryOn) == 0 {
+		return true
+	}
+	for _, c := range p.RetryOn {
+		if c == class {
+			return true
+		}
 	}
+	return false
+}
+
+//Backoff returns the pause before the given retry (starting at 1)
+//without jitter applied
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	backoff := float64(p.InitialBackoff)
+	for i := 1; i < retry; i++ {
+		backoff *= multiplier
+		if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+			return p.MaxBackoff
+		}
+	}
+
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		return p.MaxBackoff
+	}
+	return time.Duration(backoff)
+}
+
+//RedactedValue replaces secrets in printed or saved configurations
+const RedactedValue = "<redacted>"
+
+//Redacted returns a copy of the configuration that is safe to
+//print or save, secrets are redacted when encoded and this also
+//redacts the overrides of secret keys
+func (c Configuration) Redacted() Configuration {
+	if len(c.Overrides) > 0 {
+		overrides := make([]Override, len(c.Overrides))
+		for i, o := range c.Overrides {
+			if isSecretKey(o.Key) {
+				o.Value = RedactedValue
+			}
+			overrides[i] = o
+		}
+		c.Overrides = overrides
+	}
+	return c
 }
 
 func ParseConfigFile(filename string) (Configuration, error) {
 	buf, err := ioutil.ReadFile(filename)
-	conf := Configuration{}
 
 	if err != nil {
-		return conf, err
+		return Configuration{}, err
 	}
 
-	err = yaml.Unmarshal(buf, &conf)
+	return ParseConfig(buf)
+}
 
-	return conf, err
+//ParseConfig parses a configuration in the format of the config file,
+//each override is parsed in turn over it so that only the fields it
+//sets are replaced
+func ParseConfig(buf []byte, overrides ...[]byte) (Configuration, error) {
+	return ParseProfile(buf, "", overrides...)
 }
@@ -0,0 +1,84 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+//Change is a config key whose value differs between two configurations,
+//the values of secrets are redacted
+type Change struct {
+	Key string
+	Old string
+	New string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%v: %v -> %v", c.Key, c.Old, c.New)
+}
+
+//Diff returns the keys whose value differs from before to after in the
+//order of the configuration fields. Maps and lists are compared as a
+//whole, a changed retry policy is reported as the key retry.
+func Diff(before Configuration, after Configuration) []Change {
+	return diffFields(reflect.ValueOf(before), reflect.ValueOf(after), "")
+}
+
+func diffFields(before reflect.Value, after reflect.Value, path string) []Change {
+	if before.Type() == secretType {
+		a, b := before.Interface().(Secret), after.Interface().(Secret)
+		if a.Value() == b.Value() {
+			return nil
+		}
+		return []Change{{Key: path, Old: a.String(), New: b.String()}}
+	}
+
+	if before.Kind() != reflect.Struct {
+		if reflect.DeepEqual(before.Interface(), after.Interface()) {
+			return nil
+		}
+		return []Change{{Key: path, Old: fmt.Sprint(before.Interface()), New: fmt.Sprint(after.Interface())}}
+	}
+
+	var changes []Change
+	for i := 0; i < before.NumField(); i++ {
+		field := before.Type().Field(i)
+		if field.PkgPath != "" || yamlName(field) == "-" {
+			continue
+		}
+		changes = append(changes, diffFields(before.Field(i), after.Field(i), joinPath(path, yamlName(field)))...)
+	}
+	return changes
+}
+
+//CopyKeys sets the keys of dst to their value in src, the keys
+//are the ones reported by Diff
+func CopyKeys(dst *Configuration, src Configuration, keys []string) error {
+	for _, key := range keys {
+		to, err := fieldByKey(reflect.ValueOf(dst).Elem(), key)
+		if err != nil {
+			return err
+		}
+		from, err := fieldByKey(reflect.ValueOf(src), key)
+		if err != nil {
+			return err
+		}
+		to.Set(from)
+	}
+	return nil
+}
+
+func fieldByKey(v reflect.Value, key string) (reflect.Value, error) {
+	for _, segment := range strings.Split(key, ".") {
+		if v.Kind() != reflect.Struct || v.Type() == secretType {
+			return reflect.Value{}, fmt.Errorf("config key %v cannot be copied", key)
+		}
+		field, ok := yamlField(v.Type(), segment)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("unknown config key %v", key)
+		}
+		v = v.FieldByIndex(field.Index)
+	}
+	return v, nil
+}
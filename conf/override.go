@@ -0,0 +1,190 @@
+package conf
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"strings"
+)
+
+const (
+	//EnvPrefix starts the environment variables that override config
+	//keys, THESIS_CLIENT_SIGNERNODE_T overrides signernode.t
+	EnvPrefix = "THESIS_CLIENT_"
+
+	OverrideFromEnv = "env"
+	OverrideFromCLI = "cli"
+)
+
+//Override replaces the value of a single config key, such as
+//signernode.scheme, after the config file and profile are parsed
+type Override struct {
+	Key   string
+	Value string
+	//Source is where the override came from, env or cli
+	Source string
+}
+
+func (o Override) String() string {
+	return fmt.Sprintf("%v=%v (%v)", o.Key, o.Value, o.Source)
+}
+
+//ParseSetting parses a key=value setting given on the command line
+func ParseSetting(setting string) (Override, error) {
+	parts := strings.SplitN(setting, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return Override{}, fmt.Errorf("invalid setting %q, expected key=value", setting)
+	}
+
+	key, err := canonicalKey(strings.Split(parts[0], "."))
+	if err != nil {
+		return Override{}, err
+	}
+	return Override{Key: key, Value: parts[1], Source: OverrideFromCLI}, nil
+}
+
+//RedactSettings returns the key=value settings as given with
+//the values of secret keys replaced by RedactedValue
+func RedactSettings(settings []string) []string {
+	redacted := make([]string, len(settings))
+	for i, setting := range settings {
+		redacted[i] = setting
+		if o, err := ParseSetting(setting); err == nil && isSecretKey(o.Key) {
+			redacted[i] = strings.SplitN(setting, "=", 2)[0] + "=" + RedactedValue
+		}
+	}
+	return redacted
+}
+
+//EnvOverrides returns the overrides of the environment variables,
+//given as KEY=value, that start with EnvPrefix. The path of the key
+//is separated by underscores and matched ignoring case. The variables
+//that name no config key, or a key inside a map whose keys can hold
+//underscores, dashes or capitals, are skipped and returned as warnings,
+//--set overrides those keys.
+func EnvOverrides(environ []string) ([]Override, []error) {
+	var overrides []Override
+	var skipped []error
+	for _, v := range environ {
+		if !strings.HasPrefix(v, EnvPrefix) {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimPrefix(v, EnvPrefix), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		path := strings.Split(strings.ToLower(parts[0]), "_")
+		if inMap(path) {
+			skipped = append(skipped, fmt.Errorf("ignoring %v%v: it sets a key inside a map, set it with --set", EnvPrefix, parts[0]))
+			continue
+		}
+		key, err := canonicalKey(path)
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("ignoring %v%v: %v", EnvPrefix, parts[0], err))
+			continue
+		}
+		overrides = append(overrides, Override{Key: key, Value: parts[1], Source: OverrideFromEnv})
+	}
+	return overrides, skipped
+}
+
+//inMap reports if the path goes through a map of the configuration
+func inMap(path []string) bool {
+	for i := 1; i < len(path); i++ {
+		if _, t, err := resolveKey(path[:i]); err == nil && t.Kind() == reflect.Map {
+			return true
+		}
+	}
+	return false
+}
+
+//applyOverrides applies the overrides in order and records them in
+//the configuration, values are parsed as YAML so lists can be given
+//in flow style, such as [a, b]
+func applyOverrides(c *Configuration, overrides []Override) error {
+	for _, o := range overrides {
+		var value yaml.Node
+		if err := yaml.Unmarshal([]byte(o.Value), &value); err != nil {
+			return fmt.Errorf("invalid value of %v: %v", o.Key, err)
+		}
+
+		node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str"}
+		if len(value.Content) > 0 {
+			node = value.Content[0]
+		}
+
+		path := strings.Split(o.Key, ".")
+		for i := len(path) - 1; i >= 0; i-- {
+			key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: path[i]}
+			node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{key, node}}
+		}
+
+		if err := decodeNode(node, c); err != nil {
+			return fmt.Errorf("invalid value of %v: %v", o.Key, err)
+		}
+		c.Overrides = append(c.Overrides, o)
+	}
+	return nil
+}
+
+//canonicalKey checks that the path names a config key and returns it
+//with the names of the config file, map keys are kept as given
+func canonicalKey(path []string) (string, error) {
+	key, t, err := resolveKey(path)
+	if err != nil {
+		return "", err
+	}
+	if (t.Kind() == reflect.Struct && t != secretType) || t.Kind() == reflect.Map {
+		return "", fmt.Errorf("config key %v is a section, set one of its fields", key)
+	}
+	return key, nil
+}
+
+//resolveKey returns the key of the path with the names of the
+//config file and the type of its value
+func resolveKey(path []string) (string, reflect.Type, error) {
+	t := reflect.TypeOf(configKeys{})
+	keys := make([]string, len(path))
+
+	for i, segment := range path {
+		if segment == "" {
+			return "", nil, fmt.Errorf("invalid config key %q", strings.Join(path, "."))
+		}
+
+		switch {
+		case t.Kind() == reflect.Struct && t != secretType:
+			field, ok := yamlField(t, segment)
+			if !ok {
+				return "", nil, fmt.Errorf("unknown config key %v", strings.Join(path[:i+1], "."))
+			}
+			keys[i] = yamlName(field)
+			t = field.Type
+		case t.Kind() == reflect.Map:
+			keys[i] = segment
+			t = t.Elem()
+		default:
+			return "", nil, fmt.Errorf("config key %v has no field %v", strings.Join(keys[:i], "."), segment)
+		}
+	}
+
+	return strings.Join(keys, "."), t, nil
+}
+
+//yamlField finds the field of the struct with the YAML name, ignoring case
+func yamlField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for key, field := range yamlFields(t) {
+		if strings.EqualFold(key, name) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func yamlName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
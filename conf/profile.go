@@ -0,0 +1,211 @@
+package conf
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+//profileFile holds the named profiles of a config file. A profile is
+//a partial configuration applied over the profile it inherits from,
+//or over the top level configuration when it inherits from none:
+//
+//	profiles:
+//	  local-docker:
+//	    conf:
+//	      validatorNodes: ["localhost:4004"]
+//	  cluster-A:
+//	    inherits: local-docker
+//	    conf:
+//	      validatorNodes: ["10.0.0.1:4004", "10.0.0.2:4004"]
+type profileFile struct {
+	Profiles map[string]yaml.Node `yaml:"profiles"`
+}
+
+type profileHeader struct {
+	Inherits string `yaml:"inherits"`
+}
+
+//LoadProfileFile loads the config file, see LoadProfile
+func LoadProfileFile(filename string, profile string, overrides []Override) (Configuration, error) {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return Configuration{}, err
+	}
+	return LoadProfile(buf, profile, overrides)
+}
+
+//LoadProfile parses a configuration with the given profile applied,
+//an empty profile selects the top level configuration, then applies
+//the overrides and resolves the secrets
+func LoadProfile(buf []byte, profile string, overrides []Override) (Configuration, error) {
+	conf, err := parseProfile(buf, profile, overrides)
+	if err != nil {
+		return conf, err
+	}
+	resolveSecrets(&conf)
+	return conf, nil
+}
+
+//LoadSubmittedProfile is LoadProfile for a configuration received from
+//another machine, such as the job of an agent. The secrets of the keys,
+//the ones the handler reads, must be given inline and references are
+//rejected, the references of other keys are dropped unresolved.
+func LoadSubmittedProfile(buf []byte, profile string, overrides []Override, keys []string) (Configuration, error) {
+	conf, err := parseProfile(buf, profile, overrides)
+	if err != nil {
+		return conf, err
+	}
+	if err := rejectReferences(&conf, keys); err != nil {
+		return conf, err
+	}
+	resolveSecrets(&conf)
+	return conf, nil
+}
+
+//ParseSubmittedProfile is ParseProfile for overrides received from
+//another machine, such as the config of a job submitted to a server.
+//The settings of this machine, such as its --set flags, are applied
+//over the profile and the overrides over them. Secret references are
+//only resolved from buf and the settings, and rejected in the overrides.
+func ParseSubmittedProfile(buf []byte, profile string, settings []Override, overrides ...[]byte) (Configuration, error) {
+	for _, override := range overrides {
+		submitted := Configuration{}
+		if err := decodeYAML(override, &submitted); err != nil {
+			return submitted, fmt.Errorf("invalid override: %v", err)
+		}
+		if err := rejectReferences(&submitted, nil); err != nil {
+			return submitted, err
+		}
+	}
+	conf, err := parseProfile(buf, profile, settings, overrides...)
+	if err != nil {
+		return conf, err
+	}
+	resolveSecrets(&conf)
+	return conf, nil
+}
+
+//ParseProfile parses a configuration with the given profile applied,
+//the overrides are parsed in turn over the profile and the secrets
+//are resolved. Keys that are not part of the configuration are
+//reported as a ValidationError.
+func ParseProfile(buf []byte, profile string, overrides ...[]byte) (Configuration, error) {
+	conf, err := parseProfile(buf, profile, nil, overrides...)
+	if err != nil {
+		return conf, err
+	}
+	resolveSecrets(&conf)
+	return conf, nil
+}
+
+//parseProfile parses the configuration with the profile, the
+//settings and the overrides applied in this order
+func parseProfile(buf []byte, profile string, settings []Override, overrides ...[]byte) (Configuration, error) {
+	conf := Configuration{}
+	problems, err := unknownKeys(buf, reflect.TypeOf(fileKeys{}))
+	if err != nil {
+		return conf, err
+	}
+	for _, override := range overrides {
+		overrideProblems, err := unknownKeys(override, reflect.TypeOf(configKeys{}))
+		if err != nil {
+			return conf, fmt.Errorf("invalid override: %v", err)
+		}
+		problems = append(problems, overrideProblems...)
+	}
+	if err := AsError(problems); err != nil {
+		return conf, err
+	}
+
+	if err := decodeYAML(buf, &conf); err != nil {
+		return conf, err
+	}
+
+	if profile != "" {
+		chain, err := profileChain(buf, profile)
+		if err != nil {
+			return conf, err
+		}
+		for _, node := range chain {
+			if err := decodeNode(node, &conf); err != nil {
+				return conf, fmt.Errorf("profile %v: %v", profile, err)
+			}
+		}
+		conf.Profile = profile
+	}
+
+	if err := applyOverrides(&conf, settings); err != nil {
+		return conf, err
+	}
+
+	for _, override := range overrides {
+		if err := decodeYAML(override, &conf); err != nil {
+			return conf, fmt.Errorf("invalid override: %v", err)
+		}
+	}
+	return conf, nil
+}
+
+//decodeYAML decodes the document over the configuration
+func decodeYAML(buf []byte, conf *Configuration) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(buf, &doc); err != nil {
+		return err
+	}
+	return decodeNode(&doc, conf)
+}
+
+//decodeNode decodes the node over the configuration, the keys
+//of the flat conf section are moved to the handler sections
+func decodeNode(node *yaml.Node, conf *Configuration) error {
+	if node.Kind == 0 {
+		//empty document
+		return nil
+	}
+	migrateLegacy(node)
+	return node.Decode(conf)
+}
+
+//profileChain returns the profile and the profiles it inherits
+//from, the most general one first
+func profileChain(buf []byte, profile string) ([]*yaml.Node, error) {
+	var file profileFile
+	if err := yaml.Unmarshal(buf, &file); err != nil {
+		return nil, err
+	}
+
+	var chain []*yaml.Node
+	seen := make(map[string]bool)
+	for name := profile; name != ""; {
+		if seen[name] {
+			return nil, fmt.Errorf("profile %v is part of an inheritance cycle", name)
+		}
+		seen[name] = true
+
+		node, ok := file.Profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown profile %q (available: %v)", name, strings.Join(profileNames(file), ", "))
+		}
+
+		var header profileHeader
+		if err := node.Decode(&header); err != nil {
+			return nil, fmt.Errorf("profile %v: %v", name, err)
+		}
+		chain = append([]*yaml.Node{&node}, chain...)
+		name = header.Inherits
+	}
+	return chain, nil
+}
+
+func profileNames(file profileFile) []string {
+	names := make([]string, 0, len(file.Profiles))
+	for name := range file.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,268 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+//Prefixes of the secret references
+const (
+	secretFromEnv      = "env:"
+	secretFromFile     = "file:"
+	secretFromKeystore = "keystore:"
+)
+
+//Secret is a config value that is given inline or as a reference
+//resolved when the configuration is loaded:
+//
+//	env:NAME       the environment variable NAME
+//	file:PATH      the contents of the file at PATH
+//	keystore:NAME  the file NAME of the keystore directory
+//
+//The value of a secret is never printed nor saved, it is redacted
+//by String and when encoded as JSON.
+type Secret struct {
+	ref      string
+	value    string
+	resolved bool
+	//err is why the reference could not be resolved
+	err error
+}
+
+//NewSecret returns a secret with the given value
+func NewSecret(value string) Secret {
+	return Secret{ref: value, value: value, resolved: true}
+}
+
+//Value returns the resolved value of the secret
+func (s Secret) Value() string {
+	return s.value
+}
+
+//IsSet reports if the secret was given
+func (s Secret) IsSet() bool {
+	return s.ref != ""
+}
+
+//isReference reports if the secret is read from a reference
+//instead of given inline
+func (s Secret) isReference() bool {
+	return !s.resolved && (strings.HasPrefix(s.ref, secretFromEnv) ||
+		strings.HasPrefix(s.ref, secretFromFile) || strings.HasPrefix(s.ref, secretFromKeystore))
+}
+
+func (s Secret) String() string {
+	if !s.IsSet() {
+		return ""
+	}
+	return RedactedValue
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+//UnmarshalJSON reads the secret of a saved result, which is redacted
+func (s *Secret) UnmarshalJSON(buf []byte) error {
+	var value string
+	if err := json.Unmarshal(buf, &value); err != nil {
+		return err
+	}
+	*s = NewSecret(value)
+	return nil
+}
+
+func (s *Secret) UnmarshalYAML(node *yaml.Node) error {
+	var ref string
+	if err := node.Decode(&ref); err != nil {
+		return err
+	}
+	*s = Secret{ref: ref}
+	return nil
+}
+
+//resolve reads the value of the reference, keystore is the
+//directory of the keystore references
+func (s *Secret) resolve(keystore string) error {
+	if s.resolved {
+		return nil
+	}
+
+	var value string
+	switch {
+	case strings.HasPrefix(s.ref, secretFromEnv):
+		name := strings.TrimPrefix(s.ref, secretFromEnv)
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return fmt.Errorf("environment variable %v is not set", name)
+		}
+		value = v
+	case strings.HasPrefix(s.ref, secretFromFile):
+		buf, err := ioutil.ReadFile(strings.TrimPrefix(s.ref, secretFromFile))
+		if err != nil {
+			return err
+		}
+		value = strings.TrimSpace(string(buf))
+	case strings.HasPrefix(s.ref, secretFromKeystore):
+		v, err := readKeystore(keystore, strings.TrimPrefix(s.ref, secretFromKeystore))
+		if err != nil {
+			return err
+		}
+		value = v
+	default:
+		value = s.ref
+	}
+
+	s.value, s.resolved = value, true
+	return nil
+}
+
+//DefaultKeystoreDir is the keystore used when the config sets none
+func DefaultKeystoreDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "keystore"
+	}
+	return filepath.Join(home, ".thesis_client", "keystore")
+}
+
+//readKeystore reads a secret of the keystore, its file must
+//only be readable by its owner
+func readKeystore(dir string, name string) (string, error) {
+	if dir == "" {
+		dir = DefaultKeystoreDir()
+	} else if strings.HasPrefix(dir, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, dir[2:])
+		}
+	}
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid keystore name %q", name)
+	}
+
+	path := filepath.Join(dir, name)
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return "", fmt.Errorf("%v can be read by other users (%v), restrict it with chmod 600", path, info.Mode().Perm())
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(buf)), nil
+}
+
+var secretType = reflect.TypeOf(Secret{})
+
+//resolveSecrets resolves every secret of the configuration, the
+//secrets that cannot be read are only reported by SecretProblems
+//so that a missing secret fails just the handlers that read it
+func resolveSecrets(c *Configuration) {
+	walkSecrets(reflect.ValueOf(c).Elem(), "", func(path string, s *Secret) []Problem {
+		s.err = s.resolve(c.Keystore)
+		return nil
+	})
+}
+
+//SecretProblems reports the secrets of the keys that could not be
+//read, a key such as http.headers covers the secrets it holds
+func (c Configuration) SecretProblems(keys []string) []Problem {
+	covered := coveredBy(keys)
+	return walkSecrets(reflect.ValueOf(&c).Elem(), "", func(path string, s *Secret) []Problem {
+		if s.err != nil && covered(path) {
+			return []Problem{Problemf(path, "%v", s.err)}
+		}
+		return nil
+	})
+}
+
+//rejectReferences reports the secrets of the keys of a configuration
+//received from another machine that are given as references, resolving
+//them would hand the files and environment of this machine to the
+//sender. The references of other keys are dropped unresolved, nil
+//keys cover every key.
+func rejectReferences(c *Configuration, keys []string) error {
+	covered := func(string) bool { return true }
+	if keys != nil {
+		covered = coveredBy(keys)
+	}
+	return AsError(walkSecrets(reflect.ValueOf(c).Elem(), "", func(path string, s *Secret) []Problem {
+		if !s.isReference() {
+			return nil
+		}
+		if !covered(path) {
+			*s = Secret{}
+			return nil
+		}
+		return []Problem{Problemf(path, "secret references are not accepted in submitted configs, give the value inline")}
+	}))
+}
+
+//coveredBy returns whether a secret key is one of keys, a key such
+//as http.headers covers the secrets it holds
+func coveredBy(keys []string) func(path string) bool {
+	wanted := make(map[string]bool)
+	for _, key := range keys {
+		wanted[key] = true
+	}
+	return func(path string) bool {
+		parent := path
+		if i := strings.LastIndex(path, "."); i >= 0 {
+			parent = path[:i]
+		}
+		return wanted[path] || wanted[parent]
+	}
+}
+
+//walkSecrets calls fn with every secret of v and its key
+func walkSecrets(v reflect.Value, path string, fn func(path string, s *Secret) []Problem) []Problem {
+	if v.Type() == secretType {
+		return fn(path, v.Addr().Interface().(*Secret))
+	}
+	if v.Kind() == reflect.Map && v.Type().Elem() == secretType {
+		//map values cannot be changed in place
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		var problems []Problem
+		for _, key := range keys {
+			secret := v.MapIndex(key).Interface().(Secret)
+			problems = append(problems, fn(joinPath(path, key.String()), &secret)...)
+			v.SetMapIndex(key, reflect.ValueOf(secret))
+		}
+		return problems
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var problems []Problem
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.PkgPath != "" || yamlName(field) == "-" {
+			continue
+		}
+		problems = append(problems, walkSecrets(v.Field(i), joinPath(path, yamlName(field)), fn)...)
+	}
+	return problems
+}
+
+//isSecretKey reports if the config key holds a secret
+func isSecretKey(key string) bool {
+	_, t, err := resolveKey(strings.Split(key, "."))
+	return err == nil && t == secretType
+}
@@ -0,0 +1,206 @@
+package conf
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"strconv"
+	"strings"
+)
+
+//SawtoothConfig is the section of the sawtooth handler
+type SawtoothConfig struct {
+	ValidatorNodes []string `yaml:"validatorNodes"`
+	//SigningKey is the hex secp256k1 key the transactions are
+	//signed with, a random key is generated when it is empty
+	SigningKey Secret `yaml:"signingKey"`
+}
+
+//SawtoothXConfig is the section of the sawtoothX handler,
+//validator i sends its batches to signer node i
+type SawtoothXConfig struct {
+	ValidatorNodes []string `yaml:"validatorNodes"`
+	SignerNodes    []string `yaml:"signerNodes"`
+	//KeyPath is the directory of the group key named KeyName,
+	//such as TBLS256_5_3
+	KeyPath string `yaml:"keyPath"`
+	KeyName string `yaml:"keyName"`
+	//SigningKey is the hex secp256k1 key the transactions are
+	//signed with, a random key is generated when it is empty
+	SigningKey Secret `yaml:"signingKey"`
+}
+
+//SignerNodeConfig is the section of the signernode handler
+type SignerNodeConfig struct {
+	SignerNodes []string `yaml:"signerNodes"`
+
+	//Permissionless settings, the client generates and installs
+	//a key of the scheme with n shares of which t sign
+	IsPermissionless       bool   `yaml:"isPermissionless"`
+	IsOneTimeKey           bool   `yaml:"isOneTimeKey"`
+	IsGroupRandomGenerated bool   `yaml:"isGroupRandomGenerated"`
+	N                      int    `yaml:"n"`
+	T                      int    `yaml:"t"`
+	Scheme                 string `yaml:"scheme"`
+
+	SendSignatureToAlgorand bool `yaml:"sendSignatureToAlgorand"`
+	//Algorand nodes the signatures are sent to
+	Algorand AlgorandConfig `yaml:"algorand"`
+}
+
+//AlgorandConfig is the section of the algorand handler
+type AlgorandConfig struct {
+	Nodes []string `yaml:"nodes"`
+	//Token of the algod API
+	Token Secret `yaml:"token"`
+
+	//Payment sent by each request, from the account of the
+	//mnemonic to the receiver address, in microAlgos
+	Mnemonic Secret `yaml:"mnemonic"`
+	Receiver string `yaml:"receiver"`
+	Amount   uint64 `yaml:"amount"`
+}
+
+//HTTPConfig is the section of the http handler
+type HTTPConfig struct {
+	//URLs are the targets, each request is sent to a random one
+	URLs   []string `yaml:"urls"`
+	Method string   `yaml:"method"`
+	//Headers are set on every request, their values are secrets
+	//since they often hold credentials, such as Authorization
+	Headers map[string]Secret `yaml:"headers"`
+	//Body is sent with every request, or else the contents of BodyFile
+	Body     string `yaml:"body"`
+	BodyFile string `yaml:"bodyFile"`
+	//ExpectedStatus lists the status codes of a successful
+	//response, empty accepts any 2xx status
+	ExpectedStatus []int `yaml:"expectedStatus"`
+}
+
+//legacyConf is the flat conf section every handler used to read
+type legacyConf struct {
+	SignerNodes             []string `yaml:"signerNodes"`
+	ValidatorNodes          []string `yaml:"validatorNodes"`
+	KeyName                 string   `yaml:"keyName"`
+	KeyPath                 string   `yaml:"keyPath"`
+	Token                   Secret   `yaml:"token"`
+	IsPermissionless        bool     `yaml:"isPermissionless"`
+	IsOneTimeKey            bool     `yaml:"isOneTimeKey"`
+	IsGroupRandomGenerated  bool     `yaml:"isGrupoRandomGenerated"`
+	N                       int      `yaml:"n"`
+	T                       int      `yaml:"t"`
+	Scheme                  string   `yaml:"scheme"`
+	SendSignatureToAlgorand bool     `yaml:"sendSignatureToAlgorand"`
+}
+
+//legacyKeys maps each key of the flat conf section to the keys
+//of the handler sections it sets. Keys set in a handler section
+//take precedence over the conf section of the same document.
+var legacyKeys = map[string][]string{
+	"signerNodes":             {"sawtoothX.signerNodes", "signernode.signerNodes"},
+	"validatorNodes":          {"sawtooth.validatorNodes", "sawtoothX.validatorNodes", "algorand.nodes", "signernode.algorand.nodes"},
+	"keyName":                 {"sawtoothX.keyName"},
+	"keyPath":                 {"sawtoothX.keyPath"},
+	"token":                   {"algorand.token", "signernode.algorand.token"},
+	"isPermissionless":        {"signernode.isPermissionless"},
+	"isOneTimeKey":            {"signernode.isOneTimeKey"},
+	"isGrupoRandomGenerated":  {"signernode.isGroupRandomGenerated"},
+	"n":                       {"signernode.n"},
+	"t":                       {"signernode.t"},
+	"scheme":                  {"signernode.scheme"},
+	"sendSignatureToAlgorand": {"signernode.sendSignatureToAlgorand"},
+}
+
+//migrateLegacy moves the keys of the flat conf section of the
+//document to the handler sections listed in legacyKeys
+func migrateLegacy(doc *yaml.Node) {
+	root := doc
+	if root.Kind == yaml.DocumentNode {
+		if len(root.Content) == 0 {
+			return
+		}
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "conf" {
+			continue
+		}
+		legacy := root.Content[i+1]
+		root.Content = append(root.Content[:i:i], root.Content[i+2:]...)
+
+		if legacy.Kind != yaml.MappingNode {
+			return
+		}
+		for k := 0; k+1 < len(legacy.Content); k += 2 {
+			for _, target := range legacyKeys[legacy.Content[k].Value] {
+				setIfAbsent(root, strings.Split(target, "."), legacy.Content[k+1])
+			}
+		}
+		return
+	}
+}
+
+//setIfAbsent sets the key at path of the mapping to value,
+//creating the mappings on the way, unless it is already set
+func setIfAbsent(mapping *yaml.Node, path []string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != path[0] {
+			continue
+		}
+		if len(path) > 1 && mapping.Content[i+1].Kind == yaml.MappingNode {
+			setIfAbsent(mapping.Content[i+1], path[1:], value)
+		}
+		return
+	}
+
+	if len(path) > 1 {
+		child := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		setIfAbsent(child, path[1:], value)
+		value = child
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: path[0]}
+	mapping.Content = append(mapping.Content, key, value)
+}
+
+//Group describes the threshold key of a run
+type Group struct {
+	Scheme  string
+	N       int
+	T       int
+	KeyName string
+}
+
+//Group returns the threshold key settings of the configuration, taken
+//from the signernode section or else the sawtoothX key name
+func (c Configuration) Group() Group {
+	if c.Conf != nil {
+		return Group{Scheme: c.Conf.Scheme, N: c.Conf.N, T: c.Conf.T, KeyName: c.Conf.KeyName}
+	}
+
+	g := Group{Scheme: c.SignerNode.Scheme, N: c.SignerNode.N, T: c.SignerNode.T, KeyName: c.SawtoothX.KeyName}
+	if g.Scheme == "" {
+		g.Scheme, g.N, g.T, _ = ParseKeyName(g.KeyName)
+	}
+	return g
+}
+
+//ParseKeyName splits a key name such as TBLS256_5_3
+//into its scheme, n and t
+func ParseKeyName(name string) (string, int, int, error) {
+	parts := strings.Split(name, "_")
+	if len(parts) != 3 {
+		return "", 0, 0, fmt.Errorf("invalid key name %q", name)
+	}
+	n, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, 0, err
+	}
+	t, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return "", 0, 0, err
+	}
+	return parts[0], n, t, nil
+}
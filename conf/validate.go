@@ -0,0 +1,236 @@
+package conf
+
+import (
+	"fmt"
+	"github.com/jffp113/Thesis_Client/Client/util"
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+//maxKeySuggestionDistance is the largest edit distance of a
+//known key suggested for an unknown one
+const maxKeySuggestionDistance = 3
+
+//Problem is a setting of the configuration that cannot work
+type Problem struct {
+	//Path of the key, such as signernode.t or retry.algorand.jitter
+	Path    string
+	Message string
+}
+
+//Problemf builds a problem of the key at path
+func Problemf(path string, format string, args ...interface{}) Problem {
+	return Problem{Path: path, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%v: %v", p.Path, p.Message)
+}
+
+//ValidationError lists every problem found in a configuration
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		lines[i] = "  " + p.String()
+	}
+	return fmt.Sprintf("invalid configuration, %v problems:\n%v", len(e.Problems), strings.Join(lines, "\n"))
+}
+
+//AsError returns a ValidationError of the problems, nil if there are none
+func AsError(problems []Problem) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: problems}
+}
+
+//Validate checks the settings shared by every handler, the handlers
+//check the settings they read themselves
+func (c Configuration) Validate() []Problem {
+	var problems []Problem
+
+	names := make([]string, 0, len(c.Retry))
+	for name := range c.Retry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		policy := c.Retry[name]
+		path := "retry." + name
+		if policy.MaxAttempts < 0 {
+			problems = append(problems, Problemf(path+".maxAttempts", "%v is negative", policy.MaxAttempts))
+		}
+		if policy.InitialBackoff < 0 {
+			problems = append(problems, Problemf(path+".initialBackoff", "%v is negative", policy.InitialBackoff))
+		}
+		if policy.MaxBackoff > 0 && policy.MaxBackoff < policy.InitialBackoff {
+			problems = append(problems, Problemf(path+".maxBackoff", "%v is lower than initialBackoff (%v)",
+				policy.MaxBackoff, policy.InitialBackoff))
+		}
+		if policy.Jitter < 0 || policy.Jitter > 1 {
+			problems = append(problems, Problemf(path+".jitter", "%v is not between 0 and 1", policy.Jitter))
+		}
+	}
+
+	if c.Pacing.Rate < 0 {
+		problems = append(problems, Problemf("pacing.rate", "%v is negative", c.Pacing.Rate))
+	}
+	t := c.Pacing.ThinkTime
+	switch t.Distribution {
+	case "", "none", "exponential":
+	case "constant":
+		if t.Value < 0 {
+			problems = append(problems, Problemf("pacing.thinkTime.value", "%v is negative", t.Value))
+		}
+	case "uniform":
+		if t.Max < t.Min {
+			problems = append(problems, Problemf("pacing.thinkTime.max", "%v is lower than min (%v)", t.Max, t.Min))
+		}
+	case "file":
+		if t.File == "" {
+			problems = append(problems, Problemf("pacing.thinkTime.file", "is required by the file distribution"))
+		}
+	default:
+		problems = append(problems, Problemf("pacing.thinkTime.distribution",
+			"unknown distribution %q, expected constant, uniform, exponential or file", t.Distribution))
+	}
+	return problems
+}
+
+//NotEmpty returns a problem if the list at path is empty
+func NotEmpty(path string, values []string) []Problem {
+	if len(values) == 0 {
+		return []Problem{Problemf(path, "is empty")}
+	}
+	return nil
+}
+
+//configKeys, fileKeys and profileKeys describe the keys allowed in
+//a configuration, in the config file and in each of its profiles
+type configKeys struct {
+	Configuration `yaml:",inline"`
+	Conf          legacyConf `yaml:"conf"`
+}
+
+type fileKeys struct {
+	configKeys `yaml:",inline"`
+	Profiles   map[string]profileKeys `yaml:"profiles"`
+}
+
+type profileKeys struct {
+	Inherits   string `yaml:"inherits"`
+	configKeys `yaml:",inline"`
+}
+
+//unknownKeys returns a problem for every key of the YAML document
+//that is not a field of t, so that typos are not silently ignored
+func unknownKeys(buf []byte, t reflect.Type) ([]Problem, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(buf, &doc); err != nil {
+		return nil, err
+	}
+	return checkKeys(&doc, t, ""), nil
+}
+
+func checkKeys(node *yaml.Node, t reflect.Type, path string) []Problem {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil
+		}
+		return checkKeys(node.Content[0], t, path)
+	case yaml.AliasNode:
+		return checkKeys(node.Alias, t, path)
+	case yaml.SequenceNode:
+		if t.Kind() != reflect.Slice {
+			return nil
+		}
+		var problems []Problem
+		for i, item := range node.Content {
+			problems = append(problems, checkKeys(item, t.Elem(), fmt.Sprintf("%v[%v]", path, i))...)
+		}
+		return problems
+	case yaml.MappingNode:
+	default:
+		return nil
+	}
+
+	var problems []Problem
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		keyPath := joinPath(path, key.Value)
+
+		switch t.Kind() {
+		case reflect.Map:
+			problems = append(problems, checkKeys(value, t.Elem(), keyPath)...)
+		case reflect.Struct:
+			if key.Value == "<<" {
+				problems = append(problems, checkKeys(value, t, path)...)
+				continue
+			}
+			fields := yamlFields(t)
+			field, ok := fields[key.Value]
+			if !ok {
+				problems = append(problems, Problem{Path: keyPath, Message: unknownKeyMessage(key, fields)})
+				continue
+			}
+			problems = append(problems, checkKeys(value, field.Type, keyPath)...)
+		}
+	}
+	return problems
+}
+
+func unknownKeyMessage(key *yaml.Node, fields map[string]reflect.StructField) string {
+	msg := fmt.Sprintf("unknown key on line %v", key.Line)
+
+	best := maxKeySuggestionDistance + 1
+	var suggestion string
+	for name := range fields {
+		d := util.Levenshtein(strings.ToLower(key.Value), strings.ToLower(name))
+		if d < best || (d == best && name < suggestion) {
+			best, suggestion = d, name
+		}
+	}
+	if suggestion != "" {
+		msg += fmt.Sprintf(", did you mean %v?", suggestion)
+	}
+	return msg
+}
+
+//yamlFields returns the fields of the struct by YAML name,
+//including the fields of inlined structs
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := yamlName(field)
+		switch {
+		case strings.Contains(field.Tag.Get("yaml"), ",inline"):
+			for name, inlined := range yamlFields(field.Type) {
+				fields[name] = inlined
+			}
+		case name == "-" || field.PkgPath != "":
+		default:
+			fields[name] = field
+		}
+	}
+	return fields
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/jffp113/Thesis_Client/Client"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+)
+
+type agentCommand struct {
+	Listen string `long:"listen" default:"localhost:7070" description:"Address the agent listens on, use :7070 with --token to accept coordinators on other machines"`
+	Token  string `long:"token" env:"THESIS_AGENT_TOKEN" description:"Token the coordinator must send, required unless the agent listens on a loopback address"`
+}
+
+func (c *agentCommand) Execute(args []string) error {
+	if c.Token == "" && !loopbackAddress(c.Listen) {
+		return fmt.Errorf("the agent listens on %v, set --token so that only the coordinator can start runs", c.Listen)
+	}
+
+	agent := Client.NewAgent()
+	agent.SetToken(c.Token)
+
+	fmt.Printf("Agent listening on %v\n", c.Listen)
+	return http.ListenAndServe(c.Listen, agent)
+}
+
+type coordinateCommand struct {
+	Handler string `short:"a" long:"handler" default:"http" description:"Handler to be executed (see the handlers command)"`
+	workloadOptions
+	outputOptions
+	Agents     []string `long:"agent" required:"true" description:"Address of an agent (host:port), repeat for each agent"`
+	AgentToken string   `json:"-" long:"agent-token" env:"THESIS_AGENT_TOKEN" description:"Token of the agents, the one given to their --token"`
+	StartDelay int      `long:"start-delay" default:"5" description:"Seconds between sending the run and starting the workers"`
+}
+
+func (c *coordinateCommand) Execute(args []string) error {
+	printer, err := Client.GetPrinter(c.Printer)
+	if err != nil {
+		return err
+	}
+
+	configData, err := ioutil.ReadFile(opts.Config)
+	if err != nil {
+		return err
+	}
+
+	overrides, err := configOverrides()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	coordinator := Client.NewCoordinator(c.Agents)
+	coordinator.SetStartDelay(time.Second * time.Duration(c.StartDelay))
+	coordinator.SetToken(c.AgentToken)
+	coordinator.SetGlobalOptions(globalOptions())
+
+	job := Client.AgentJob{
+		Handler:     c.Handler,
+		Concurrency: c.ConcurrentClient,
+		Duration:    time.Second * time.Duration(c.Duration),
+		Seed:        c.Seed,
+		KeepSamples: (c.KeepSamples + len(c.Agents) - 1) / len(c.Agents),
+		Profile:     opts.Profile,
+		Overrides:   overrides,
+		Options:     c,
+	}
+	result, err := coordinator.Run(ctx, job, configData)
+	if err != nil {
+		return err
+	}
+
+	printer.Print(result.Stats)
+	Client.PrintSources(result.Sources)
+
+	if !c.NoStore {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		if err := store.Append(result); err != nil {
+			return err
+		}
+	}
+	if c.Output != "" {
+		return Client.SaveResult(c.Output, result)
+	}
+	return nil
+}
+
+//loopbackAddress reports if the listen address only accepts
+//connections from this machine
+func loopbackAddress(listen string) bool {
+	host, _, err := net.SplitHostPort(listen)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
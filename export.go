@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"github.com/jffp113/Thesis_Client/Client"
+	"github.com/jffp113/Thesis_Client/Client/report"
+	"os"
+	"strings"
+)
+
+type exportCommand struct {
+	runFilterOptions
+	Rows   string `long:"rows" default:"scheme" description:"Dimension used for the rows (handler, scheme, concurrency, duration, n, t, keyName, version)"`
+	Cols   string `long:"cols" default:"concurrency" description:"Dimension used for the columns"`
+	Cell   string `long:"cell" default:"p99" description:"Metric in each cell (throughput, offered, avg, pXX, errors, errorRate, retries)"`
+	Format string `short:"f" long:"format" default:"latex" choice:"latex" choice:"markdown" description:"Output format"`
+
+	Args struct {
+		Runs []string `positional-arg-name:"run" description:"Result files or stored run ids, all runs matching the filters when empty"`
+	} `positional-args:"yes"`
+}
+
+func (c *exportCommand) Execute(args []string) error {
+	results, err := c.results()
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("no runs selected")
+	}
+
+	table, err := report.BuildTable(results, c.Rows, c.Cols, c.Cell)
+	if err != nil {
+		return err
+	}
+
+	if c.Format == "markdown" {
+		return report.WriteMarkdown(os.Stdout, table)
+	}
+	return report.WriteLaTeX(os.Stdout, table)
+}
+
+//results loads the runs given as arguments or, if none are
+//given, every stored run matching the filters
+func (c *exportCommand) results() ([]Client.Result, error) {
+	var results []Client.Result
+
+	if len(c.Args.Runs) > 0 {
+		for _, ref := range c.Args.Runs {
+			r, err := loadResultRef(ref)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, r)
+		}
+		return results, nil
+	}
+
+	store, err := openStore()
+	if err != nil {
+		return nil, err
+	}
+	filter, err := c.filter()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := store.History(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, e := range entries {
+		r, err := store.Load(e.RunID)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+		ids = append(ids, e.RunID)
+	}
+	fmt.Fprintf(os.Stderr, "Exporting %v runs: %v\n", len(ids), strings.Join(ids, " "))
+	return results, nil
+}
@@ -11,7 +11,6 @@ require (
 	github.com/jffp113/CryptoProviderSDK v0.0.8
 	github.com/jffp113/SignerNode_Thesis v0.0.7
 	github.com/jffp113/go-algorand-sdk v0.0.0-20210403130421-948312489373
-	github.com/satori/go.uuid v1.2.0
 	google.golang.org/protobuf v1.23.0
 	gopkg.in/yaml.v2 v2.4.0
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
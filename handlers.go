@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"github.com/jffp113/Thesis_Client/Client"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+type handlersCommand struct {
+}
+
+func (c *handlersCommand) Execute(args []string) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tDESCRIPTION")
+	for _, info := range Client.RegisteredHandlers() {
+		fmt.Fprintf(w, "%v\t%v\n", info.Name, info.Description)
+		if len(info.ConfigKeys) > 0 {
+			fmt.Fprintf(w, "\tconfig: %v\n", strings.Join(info.ConfigKeys, ", "))
+		}
+		if len(info.ReloadKeys) > 0 {
+			fmt.Fprintf(w, "\treloadable: %v\n", strings.Join(info.ReloadKeys, ", "))
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"github.com/jffp113/Thesis_Client/Client"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+//runFilterOptions are the flags shared by the commands
+//that select stored runs
+type runFilterOptions struct {
+	Handler     string `long:"handler" description:"Only runs of this handler"`
+	Scheme      string `long:"scheme" description:"Only runs with this signature scheme"`
+	Concurrency int    `long:"concurrency" description:"Only runs with this number of concurrent clients"`
+	Since       string `long:"since" description:"Only runs started at or after this date (2006-01-02 or RFC3339)"`
+	Until       string `long:"until" description:"Only runs started at or before this date (2006-01-02 or RFC3339)"`
+}
+
+func (o runFilterOptions) filter() (Client.StoreFilter, error) {
+	filter := Client.StoreFilter{
+		Handler:     o.Handler,
+		Scheme:      o.Scheme,
+		Concurrency: o.Concurrency,
+	}
+
+	var err error
+	if filter.Since, err = parseDate(o.Since, false); err != nil {
+		return filter, err
+	}
+	filter.Until, err = parseDate(o.Until, true)
+	return filter, err
+}
+
+type historyCommand struct {
+	runFilterOptions
+}
+
+func (c *historyCommand) Execute(args []string) error {
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+
+	filter, err := c.filter()
+	if err != nil {
+		return err
+	}
+
+	entries, err := store.History(filter)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RUN ID\tSTARTED\tHANDLER\tSCHEME\tCLIENTS\tDURATION\tREQUESTS\tERRORS\tTX/SEC")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%.2f\n",
+			e.RunID, e.StartTime.Local().Format("2006-01-02 15:04:05"), e.Handler, e.Scheme,
+			e.Concurrency, e.Duration, e.NumRequests, e.NumErrs, e.Throughput)
+	}
+	return w.Flush()
+}
+
+type showCommand struct {
+	Printer string `short:"p" long:"printer" default:"default" description:"Printer used for the stats (default, json or csv)"`
+	Args    struct {
+		RunID string `positional-arg-name:"run-id" description:"Id of the run, or a unique prefix of it"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+func (c *showCommand) Execute(args []string) error {
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+
+	printer, err := Client.GetPrinter(c.Printer)
+	if err != nil {
+		return err
+	}
+
+	result, err := store.Load(c.Args.RunID)
+	if err != nil {
+		return err
+	}
+
+	m := result.Metadata
+	fmt.Printf("Run %v: %v with %v clients for %v, started %v\n",
+		m.RunID, m.Handler, m.Concurrency, m.Duration, m.StartTime.Local().Format(time.RFC3339))
+	printer.Print(result.Stats)
+	return nil
+}
+
+//parseDate accepts a day or a RFC3339 timestamp, a day given as
+//the upper bound of a range includes the whole day
+func parseDate(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, use 2006-01-02 or RFC3339", value)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
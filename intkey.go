@@ -0,0 +1,37 @@
+package main
+
+import (
+	"github.com/jffp113/Thesis_Client/conf"
+)
+
+type intkeyCommand struct {
+	Extended    bool     `long:"extended" description:"Have the transactions signed by the signer nodes (sawtoothX handler) instead of the client"`
+	Validators  []string `long:"validator" description:"Address of a Sawtooth validator, repeat for each validator (default: validatorNodes of the sawtooth or sawtoothX section)"`
+	SignerNodes []string `long:"signer-node" description:"Address of a signer node, repeat for each node (default: sawtoothX.signerNodes)"`
+	KeyPath     string   `long:"key-path" description:"Directory of the group key used with --extended (default: sawtoothX.keyPath)"`
+	KeyName     string   `long:"key-name" description:"Name of the group key used with --extended (default: sawtoothX.keyName)"`
+	benchmarkOptions
+}
+
+func (c *intkeyCommand) Execute(args []string) error {
+	handler := "sawtooth"
+	if c.Extended {
+		handler = "sawtoothX"
+	}
+
+	return c.run(handler, c, func(config *conf.Configuration) {
+		if len(c.Validators) > 0 {
+			config.Sawtooth.ValidatorNodes = c.Validators
+			config.SawtoothX.ValidatorNodes = c.Validators
+		}
+		if len(c.SignerNodes) > 0 {
+			config.SawtoothX.SignerNodes = c.SignerNodes
+		}
+		if c.KeyPath != "" {
+			config.SawtoothX.KeyPath = c.KeyPath
+		}
+		if c.KeyName != "" {
+			config.SawtoothX.KeyName = c.KeyName
+		}
+	})
+}
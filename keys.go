@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"github.com/jffp113/Thesis_Client/Handlers/SignerNode"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+type keysCommand struct {
+	Generate keysGenerateCommand `command:"generate" description:"Generate the key shares of a group"`
+	List     keysListCommand     `command:"list" description:"List the keys of a key directory"`
+}
+
+//keysGenerateCommand writes the keys in the layout read by the keychain,
+//directory i holds the group public key and the private share of node i
+type keysGenerateCommand struct {
+	Scheme string `long:"scheme" default:"TBLS256" description:"Signature scheme (TBLS256, TRSA1024 or TRSA2048)"`
+	N      int    `short:"n" default:"5" description:"Number of shares"`
+	T      int    `short:"t" default:"3" description:"Number of shares needed to sign"`
+	Out    string `long:"out" default:"resources/keys" description:"Directory where the keys are written"`
+	Force  bool   `long:"force" description:"Overwrite existing keys with the same name"`
+}
+
+func (c *keysGenerateCommand) Execute(args []string) error {
+	if c.T < 1 || c.T > c.N {
+		return fmt.Errorf("t must be between 1 and n (%v), got %v", c.N, c.T)
+	}
+	gen := SignerNode.KeyGenerator(c.Scheme)
+	if gen == nil {
+		return fmt.Errorf("unsupported scheme %v", c.Scheme)
+	}
+
+	name := fmt.Sprintf("%v_%v_%v", c.Scheme, c.N, c.T)
+	if !c.Force {
+		if _, err := os.Stat(filepath.Join(c.Out, "1", "pub_"+name)); err == nil {
+			return fmt.Errorf("key %v already exists in %v, use --force to overwrite it", name, c.Out)
+		}
+	}
+
+	pub, privs := gen.Gen(c.N, c.T)
+	pubBytes, err := pub.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	for i, priv := range privs {
+		dir := filepath.Join(c.Out, fmt.Sprint(i+1))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		privBytes, err := priv.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "pub_"+name), pubBytes, 0644); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "priv_"+name), privBytes, 0600); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Generated key %v in %v/1 to %v/%v\n", name, c.Out, c.Out, c.N)
+	return nil
+}
+
+type keysListCommand struct {
+	Dir string `long:"dir" default:"resources/keys" description:"Directory of the keys"`
+}
+
+func (c *keysListCommand) Execute(args []string) error {
+	dirs, err := ioutil.ReadDir(c.Dir)
+	if err != nil {
+		return err
+	}
+
+	//shares of each key name by share directory
+	shares := make(map[string][]string)
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		files, err := ioutil.ReadDir(filepath.Join(c.Dir, dir.Name()))
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if strings.HasPrefix(f.Name(), "priv_") {
+				name := strings.TrimPrefix(f.Name(), "priv_")
+				shares[name] = append(shares[name], dir.Name())
+			}
+		}
+	}
+
+	names := make([]string, 0, len(shares))
+	for name := range shares {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tSHARES\tDIRECTORIES")
+	for _, name := range names {
+		sort.Strings(shares[name])
+		fmt.Fprintf(w, "%v\t%v\t%v\n", name, len(shares[name]), strings.Join(shares[name], ","))
+	}
+	return w.Flush()
+}
@@ -4,21 +4,26 @@ import (
 	"fmt"
 	"github.com/jessevdk/go-flags"
 	"github.com/jffp113/Thesis_Client/Client"
-	"github.com/jffp113/Thesis_Client/Handlers/Algorand"
-	"github.com/jffp113/Thesis_Client/Handlers/SawtoothBaseIntKey"
-	SawtoothExtendedIntKey "github.com/jffp113/Thesis_Client/Handlers/SawtoothExtendedIntKey"
-	"github.com/jffp113/Thesis_Client/Handlers/SignerNode"
-	"github.com/jffp113/Thesis_Client/Handlers/SimpleHttp"
+	//Handlers register themselves when imported
+	_ "github.com/jffp113/Thesis_Client/Handlers/Algorand"
+	_ "github.com/jffp113/Thesis_Client/Handlers/SawtoothBaseIntKey"
+	_ "github.com/jffp113/Thesis_Client/Handlers/SawtoothExtendedIntKey"
+	_ "github.com/jffp113/Thesis_Client/Handlers/SignerNode"
+	_ "github.com/jffp113/Thesis_Client/Handlers/SimpleHttp"
+	"github.com/jffp113/Thesis_Client/conf"
 	"os"
-	"time"
 )
 
+//Opts are the options shared by every command
 type Opts struct {
-	ConcurrentClient int    `short:"c" long:"concurrent" default:"1" description:"Number Of Concurrent Clients"`
-	Duration         int    `short:"d" long:"duration"   default:"10" description:"Duration in Seconds"`
-	Handler          string `short:"a" long:"handler"  default:"http" description:"Handler to be executed"`
+	Config  string   `long:"config" default:"conf.yaml" description:"Config file with the nodes, keys and handler settings"`
+	Profile string   `long:"profile" description:"Profile of the config file to use, see the profiles section of conf.yaml"`
+	Set     []string `long:"set" value-name:"KEY=VALUE" description:"Override a config key, such as signernode.scheme=TRSA1024, repeat for each key. Environment variables such as THESIS_CLIENT_SIGNERNODE_T=4 are applied before them"`
+	Store   string   `long:"store" description:"Directory of the results store (default: ~/.thesis_client/results)"`
 }
 
+var opts Opts
+
 func main() {
 	defer func() {
 		if r := recover(); r != nil {
@@ -26,40 +31,102 @@ func main() {
 		}
 	}()
 
-	var opts Opts
-
 	parser := flags.NewParser(&opts, flags.Default)
-	remaining, err := parser.Parse()
+	addCommands(parser)
+
+	_, err := parser.Parse()
 
 	if err != nil {
 		if flagsErr, ok := err.(*flags.Error); ok && flagsErr.Type == flags.ErrHelp {
 			os.Exit(0)
+		} else if !ok {
+			//Errors returned by commands are already printed by the parser
+			os.Exit(1)
 		} else {
 			fmt.Printf("Failed to parse args: %v\n", err)
 			os.Exit(2)
 		}
 	}
+}
 
-	if len(remaining) > 0 {
-		fmt.Printf("Error: Unrecognized arguments passed: %v\n", remaining)
-		os.Exit(2)
+func addCommands(parser *flags.Parser) {
+	parser.AddCommand("run", "Run a benchmark",
+		"Send requests with the handler given with -a for the given duration and report the stats", &runCommand{})
+	parser.AddCommand("check", "Check the config and nodes of a handler",
+		"Run the preflight checks of the handler given with -a, such as node connectivity and key loading, without generating load", &checkCommand{})
+	parser.AddCommand("intkey", "Run a benchmark of Sawtooth intkey",
+		"Send intkey increments to the Sawtooth validators, signed by the client or, with --extended, by the signer nodes", &intkeyCommand{})
+	parser.AddCommand("algorand", "Run a benchmark of Algorand payments",
+		"Send payment transactions to the Algorand nodes and wait for their confirmation", &algorandCommand{})
+	parser.AddCommand("keys", "Generate and list threshold keys",
+		"Generate the key shares of a group or list the keys of a key directory", &keysCommand{})
+	parser.AddCommand("handlers", "List the available handlers",
+		"List the handlers that can be passed to -a with the config keys they read", &handlersCommand{})
+	parser.AddCommand("history", "List stored runs",
+		"List the runs in the results store, optionally filtered", &historyCommand{})
+	parser.AddCommand("show", "Print a stored run",
+		"Print the stats of a stored run with any printer", &showCommand{})
+	parser.AddCommand("compare", "Compare two results",
+		"Compare throughput and latency percentiles of two result files or stored runs", &compareCommand{})
+	parser.AddCommand("report", "Generate an HTML report",
+		"Generate a self contained HTML report with charts from a result file or stored run", &reportCommand{})
+	parser.AddCommand("export", "Export runs as a LaTeX or markdown table",
+		"Pivot a set of runs into a table with chosen row and column dimensions and cell metric", &exportCommand{})
+	parser.AddCommand("merge", "Merge results of clients that ran at the same time",
+		"Combine the result files or stored runs of independent clients into one result, "+
+			"latencies are merged from every sample and time series are aligned on wall clock time", &mergeCommand{})
+	parser.AddCommand("serve", "Run as a daemon driven over HTTP",
+		"Serve an HTTP/JSON API to submit benchmark jobs, follow their live stats, cancel them and fetch their results. "+
+			"Jobs run one at a time.", &serveCommand{})
+	parser.AddCommand("agent", "Run as a load generation agent",
+		"Wait for runs from a coordinator and answer with their results", &agentCommand{})
+	parser.AddCommand("coordinate", "Run the benchmark on several agents",
+		"Start the benchmark on every agent at the same time and merge their results. "+
+			"The -a, -c and -d options apply to each agent, the config file and profile are sent to them.", &coordinateCommand{})
+}
+
+//loadConfig parses the config file with the selected profile
+//and applies the overrides of the environment and command line
+func loadConfig() (conf.Configuration, error) {
+	overrides, err := configOverrides()
+	if err != nil {
+		return conf.Configuration{}, err
 	}
+	return conf.LoadProfileFile(opts.Config, opts.Profile, overrides)
+}
 
-	reqCli := Client.NewRequester()
-	reqCli.SetConcurrentClients(opts.ConcurrentClient)
-	reqCli.SetConfigFilePath("conf.yaml")
-	reqCli.SetDuration(time.Second * time.Duration(opts.Duration))
+//configOverrides returns the overrides of config keys, the
+//environment ones first so that --set takes precedence
+func configOverrides() ([]conf.Override, error) {
+	overrides, skipped := conf.EnvOverrides(os.Environ())
+	for _, err := range skipped {
+		fmt.Printf("Warning: %v\n", err)
+	}
 
-	reqCli.AddHandler("http", SimpleHttp.NewHandler())
-	reqCli.AddHandler("sawtooth", SawtoothBaseIntKey.NewHandler())
-	reqCli.AddHandler("sawtoothX", SawtoothExtendedIntKey.NewHandler())
-	reqCli.AddHandler("signernode", SignerNode.NewHandler())
-	reqCli.AddHandler("algorand", Algorand.NewHandler())
-	err = reqCli.Start(opts.Handler)
+	for _, setting := range opts.Set {
+		o, err := conf.ParseSetting(setting)
+		if err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, nil
+}
 
-	if err != nil {
-		fmt.Println("Error: ", err)
-		os.Exit(2)
+//globalOptions returns the global options recorded in the metadata of a run
+func globalOptions() Client.GlobalOptions {
+	return Client.GlobalOptions{
+		Config:  opts.Config,
+		Profile: opts.Profile,
+		Store:   opts.Store,
+		Set:     conf.RedactSettings(opts.Set),
 	}
+}
 
+func openStore() (*Client.Store, error) {
+	dir := opts.Store
+	if dir == "" {
+		dir = Client.DefaultStoreDir()
+	}
+	return Client.OpenStore(dir)
 }
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"github.com/jffp113/Thesis_Client/Client"
+)
+
+//minOverlap is the share of the runs that must overlap
+//before the merged throughput is trusted
+const minOverlap = 0.9
+
+type mergeCommand struct {
+	Output  string `short:"o" long:"output" description:"File where the merged result is saved as JSON"`
+	Printer string `short:"p" long:"printer" default:"default" description:"Printer used for the merged stats (default, json or csv)"`
+	NoStore bool   `long:"no-store" description:"Do not save the merged result in the results store"`
+
+	Args struct {
+		Runs []string `positional-arg-name:"run" description:"Result files or stored run ids" required:"2"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+func (c *mergeCommand) Execute(args []string) error {
+	printer, err := Client.GetPrinter(c.Printer)
+	if err != nil {
+		return err
+	}
+
+	var results []Client.Result
+	var sources []Client.Source
+	for _, ref := range c.Args.Runs {
+		result, err := loadResultRef(ref)
+		if err != nil {
+			return fmt.Errorf("%v: %v", ref, err)
+		}
+		results = append(results, result)
+		sources = append(sources, Client.Source{Name: ref})
+	}
+
+	merged, err := Client.MergeResults(results, sources)
+	if err != nil {
+		return err
+	}
+
+	printer.Print(merged.Stats)
+	Client.PrintSources(merged.Sources)
+	if overlap := Client.Overlap(results, sources); overlap < minOverlap {
+		fmt.Printf("Warning: the runs overlap for only %.0f%% of the shortest one, "+
+			"the merged throughput overstates the load that was sent at the same time\n", overlap*100)
+	}
+
+	if !c.NoStore {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		if err := store.Append(merged); err != nil {
+			return err
+		}
+	}
+	if c.Output != "" {
+		return Client.SaveResult(c.Output, merged)
+	}
+	return nil
+}
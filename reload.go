@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+//configPollInterval is how often a watched config file is checked
+const configPollInterval = time.Second
+
+//reloadTrigger fires on SIGHUP and each time the modification time
+//of the config file changes, until ctx is done. It is only installed
+//with --watch-config so that SIGHUP keeps its default behavior otherwise
+func reloadTrigger(ctx context.Context, path string) <-chan struct{} {
+	trigger := make(chan struct{}, 1)
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hangup)
+
+		ticker := time.NewTicker(configPollInterval)
+		defer ticker.Stop()
+		var modTime time.Time
+		if info, err := os.Stat(path); err == nil {
+			modTime = info.ModTime()
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hangup:
+			case <-ticker.C:
+				info, err := os.Stat(path)
+				if err != nil || info.ModTime().Equal(modTime) {
+					continue
+				}
+				modTime = info.ModTime()
+			}
+
+			//a reload already pending reads the latest file
+			select {
+			case trigger <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return trigger
+}
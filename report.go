@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"github.com/jffp113/Thesis_Client/Client/report"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type reportCommand struct {
+	Output string `short:"o" long:"output" description:"HTML file to write (default: the result name with .html)"`
+
+	Args struct {
+		Result string `positional-arg-name:"result" description:"Result file or stored run id"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+func (c *reportCommand) Execute(args []string) error {
+	result, err := loadResultRef(c.Args.Result)
+	if err != nil {
+		return err
+	}
+
+	output := c.Output
+	if output == "" {
+		output = result.Metadata.RunID + ".html"
+		if _, err := os.Stat(c.Args.Result); err == nil {
+			output = strings.TrimSuffix(c.Args.Result, filepath.Ext(c.Args.Result)) + ".html"
+		}
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := report.WriteHTML(f, result); err != nil {
+		return err
+	}
+
+	fmt.Printf("Report written to %v\n", output)
+	return nil
+}
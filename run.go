@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/jffp113/Thesis_Client/Client"
+	"github.com/jffp113/Thesis_Client/Client/tui"
+	"github.com/jffp113/Thesis_Client/conf"
+	"os"
+	"time"
+)
+
+//workloadOptions describe the load sent by the commands that run benchmarks
+type workloadOptions struct {
+	ConcurrentClient int   `short:"c" long:"concurrent" default:"1" description:"Number Of Concurrent Clients"`
+	Duration         int   `short:"d" long:"duration"   default:"10" description:"Duration in Seconds"`
+	Seed             int64 `long:"seed" description:"Seed of every random choice, such as node selection, to reproduce a run (default: seed of the config or the clock)"`
+	KeepSamples      int   `long:"keep-samples" default:"100000" description:"Number of requests kept as samples in the result for compare, the percentiles cover every request"`
+}
+
+//outputOptions tell where the result of a command goes
+type outputOptions struct {
+	Output  string `short:"o" long:"output" description:"File where the result is saved as JSON"`
+	NoStore bool   `long:"no-store" description:"Do not save the result in the results store"`
+	Printer string `short:"p" long:"printer" default:"default" description:"Printer used for the stats (default, json or csv)"`
+}
+
+//benchmarkOptions are the flags of every command that runs a benchmark
+//on this machine, handler specific commands add their own
+type benchmarkOptions struct {
+	workloadOptions
+	outputOptions
+	Repeat     int    `long:"repeat" default:"1" description:"Number of times the benchmark is repeated"`
+	Pause      int    `long:"pause" default:"0" description:"Seconds to wait between repetitions"`
+	TUI        bool   `long:"tui" description:"Show a live dashboard while the benchmark runs"`
+	CPUProfile string `long:"cpuprofile" description:"Write a CPU profile of the measurement window to this file"`
+	MemProfile string `long:"memprofile" description:"Write a heap profile at the end of the measurement window to this file"`
+	Trace      string `long:"trace" description:"Write an execution trace of the measurement window to this file"`
+	PprofAddr  string `long:"pprof-addr" description:"Serve pprof on this address (e.g. localhost:6060) during the measurement window"`
+	DryRun     bool   `long:"dry-run" description:"Run the preflight checks of the handler instead of the benchmark"`
+	//With it the config file is also reloaded on SIGHUP
+	WatchConfig bool `long:"watch-config" description:"Apply the changes of the config file while the benchmark runs, such as nodes and pacing.rate (SIGHUP then also reloads it)"`
+}
+
+type runCommand struct {
+	Handler string `short:"a" long:"handler" default:"http" description:"Handler to be executed (see the handlers command)"`
+	benchmarkOptions
+}
+
+func (c *runCommand) Execute(args []string) error {
+	return c.run(c.Handler, c, nil)
+}
+
+//run runs the benchmark with the handler, override changes the config
+//file with the flags of the command and options are saved with the result
+func (o *benchmarkOptions) run(handlerName string, options interface{}, override func(c *conf.Configuration)) error {
+	load := func() (conf.Configuration, error) {
+		config, err := loadConfig()
+		if err != nil {
+			return config, err
+		}
+		if override != nil {
+			override(&config)
+		}
+		return config, nil
+	}
+
+	config, err := load()
+	if err != nil {
+		return err
+	}
+
+	if o.DryRun {
+		return runChecks(handlerName, config)
+	}
+
+	printer, err := Client.GetPrinter(o.Printer)
+	if err != nil {
+		return err
+	}
+
+	reqCli := Client.NewRequester()
+	reqCli.SetConcurrentClients(o.ConcurrentClient)
+	reqCli.SetConfig(config)
+	reqCli.SetDuration(time.Second * time.Duration(o.Duration))
+	reqCli.SetOutputPath(o.Output)
+	reqCli.SetRunOptions(options)
+	reqCli.SetGlobalOptions(globalOptions())
+	reqCli.SetPrinter(printer)
+	reqCli.SetRepetitions(o.Repeat, time.Second*time.Duration(o.Pause))
+	reqCli.SetSeed(o.Seed)
+	reqCli.SetKeepSamples(o.KeepSamples)
+
+	reqCli.SetProfiling(Client.ProfileOptions{
+		CPUProfile: o.CPUProfile,
+		MemProfile: o.MemProfile,
+		Trace:      o.Trace,
+		PprofAddr:  o.PprofAddr,
+	})
+
+	if o.TUI {
+		reqCli.SetLiveView(tui.NewDashboard(os.Stdout, reqCli.LiveStats))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if o.WatchConfig {
+		reqCli.SetReload(reloadTrigger(ctx, opts.Config), load)
+	}
+
+	if !o.NoStore {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		reqCli.SetStore(store)
+	}
+
+	if err := reqCli.Start(handlerName); err != nil {
+		return fmt.Errorf("run failed: %v", err)
+	}
+	return nil
+}
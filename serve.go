@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"github.com/jffp113/Thesis_Client/Client"
+)
+
+type serveCommand struct {
+	Listen  string `long:"listen" default:"localhost:9180" description:"Address the API listens on, use :9180 to accept other machines"`
+	NoStore bool   `long:"no-store" description:"Do not save the results of the jobs in the results store"`
+}
+
+func (c *serveCommand) Execute(args []string) error {
+	server := Client.NewServer(opts.Config)
+	server.SetProfile(opts.Profile)
+
+	overrides, err := configOverrides()
+	if err != nil {
+		return err
+	}
+	server.SetOverrides(overrides)
+	server.SetGlobalOptions(globalOptions())
+
+	if !c.NoStore {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		server.SetStore(store)
+	}
+
+	fmt.Printf("Serving the jobs API on %v\n", c.Listen)
+	return server.ListenAndServe(c.Listen)
+}